@@ -22,10 +22,19 @@ func main() {
 	searchService := service.NewSearchServiceWithConfig(cfg)
 
 	// Initialize API handler
-	handler := api.NewHandler(searchService)
+	handler := api.NewHandler(searchService, cfg.Server.GetSessionIdleTimeout())
+	handler.SetWebSocketOrigins(cfg.Server.WebSocketOrigins)
+
+	// The spec documents the routes and validates responses, so it must build
+	if err := api.CheckOpenAPISpec(); err != nil {
+		log.Fatalf("Failed to build OpenAPI spec: %v", err)
+	}
 
 	// Setup routes
-	router := api.SetupRoutes(handler)
+	router := api.SetupRoutes(handler, cfg.Admin.APIKey)
+	if cfg.Admin.APIKey == "" {
+		log.Printf("Warning: admin.api_key is not set; /api/v1/admin endpoints are disabled")
+	}
 
 	// Initialize rate limiter from config
 	rateLimiter := api.NewRateLimiter(
@@ -34,8 +43,11 @@ func main() {
 	)
 
 	// Add middleware (order matters!)
-	router.Use(api.RecoveryMiddleware)          // Recover from panics
-	router.Use(api.LoggingMiddleware)           // Handle CORS
+	router.Use(api.RecoveryMiddleware) // Recover from panics
+	router.Use(api.LoggingMiddleware)  // Handle CORS
+	if cfg.Server.ValidateResponses {
+		router.Use(api.ResponseValidationMiddleware) // Log responses that break the OpenAPI spec
+	}
 	router.Use(rateLimiter.RateLimitMiddleware) // Apply rate limiting
 
 	// Configure server using config from .env.yaml
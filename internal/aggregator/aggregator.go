@@ -3,29 +3,116 @@ package aggregator
 import (
 	"context"
 	"errors"
+	"flight-aggregator/internal/cache"
 	"flight-aggregator/internal/models"
 	"flight-aggregator/internal/providers"
+	"flight-aggregator/pkg/circuitbreaker"
 	"flight-aggregator/pkg/retry"
 	"fmt"
+	"log"
+	"maps"
 	"strings"
 	"sync"
 	"time"
 )
 
+// CircuitOpenError is reported in ProviderErrors for providers skipped by an open circuit
+const CircuitOpenError = "circuit_open"
+
+// Reasons reported in ProviderSkipped
+const (
+	SkipReasonRoute   = "route not served"
+	SkipReasonAirline = "airline not sold"
+)
+
+// Search errors; match them with errors.Is
+var (
+	// ErrNoProviders is returned when no provider serves the route or sells the requested airlines
+	ErrNoProviders = errors.New("no provider can serve the search")
+	// ErrNoResults is returned when providers answered but none had matching flights
+	ErrNoResults = errors.New("no flights found")
+	// ErrAllProvidersFailed is returned when every queried provider failed
+	ErrAllProvidersFailed = errors.New("all providers failed")
+)
+
+// searchError describes why a search produced no flights
+// It matches its kind (one of the search errors above) with errors.Is.
+type searchError struct {
+	kind    error
+	message string
+}
+
+func (e *searchError) Error() string {
+	return e.message
+}
+
+func (e *searchError) Unwrap() error {
+	return e.kind
+}
+
+// Cache statuses reported in ProviderCache
+const (
+	CacheStatusHit   = "hit"   // results served from the cache
+	CacheStatusStale = "stale" // results past their TTL served from the cache while being refreshed
+	CacheStatusMiss  = "miss"  // provider was queried
+)
+
 // ProviderResult represents the result from a single provider
 type ProviderResult struct {
 	Provider string
 	Flights  []models.Flight
 	Error    error
 	Duration time.Duration
+	CacheHit bool // served from the result cache without querying the provider
+	Stale    bool // cached results past their TTL; a background refresh was started
+
+	FetchedAt time.Time // when the flights were fetched from the provider
+	ExpiresAt time.Time // when the cached flights go stale; zero when they are not cached
 }
 
 // AggregatedResults contains all results from multiple providers
 type AggregatedResults struct {
-	Flights         []models.Flight
-	ProviderResults map[string]int    // provider name -> number of flights
-	ProviderErrors  map[string]string // provider name -> error message
-	TotalDuration   time.Duration
+	Flights          []models.Flight
+	ProviderResults  map[string]int    // provider name -> number of flights
+	ProviderErrors   map[string]string // provider name -> error message
+	ProviderSkipped  map[string]string // provider name -> reason it was not queried
+	ProviderPending  []string          // providers still running when the soft deadline passed
+	ProviderCache    map[string]string // provider name -> CacheStatusHit, CacheStatusStale or CacheStatusMiss
+	DuplicatesMerged int               // number of duplicate offers merged into alternates
+	TotalDuration    time.Duration
+
+	// Freshness of the successful providers' flights
+	FetchedAt time.Time // oldest fetch time, i.e. when the fares were last confirmed
+	ExpiresAt time.Time // earliest time any of them goes stale; zero if some are not cached
+}
+
+// Clone returns a deep copy of the results, so callers sharing one fan-out
+// can each build a response without sharing flights or maps
+func (r *AggregatedResults) Clone() *AggregatedResults {
+	clone := *r
+
+	clone.Flights = make([]models.Flight, len(r.Flights))
+	for i, flight := range r.Flights {
+		clone.Flights[i] = flight.Clone()
+	}
+	clone.ProviderResults = maps.Clone(r.ProviderResults)
+	clone.ProviderErrors = maps.Clone(r.ProviderErrors)
+	clone.ProviderSkipped = maps.Clone(r.ProviderSkipped)
+	clone.ProviderCache = maps.Clone(r.ProviderCache)
+	if r.ProviderPending != nil {
+		clone.ProviderPending = append([]string(nil), r.ProviderPending...)
+	}
+
+	return &clone
+}
+
+// ProviderPolicy holds the timeout and retry settings for a single provider
+type ProviderPolicy struct {
+	Timeout      time.Duration // bounds the whole query including retries
+	Retry        retry.Params
+	HedgeEnabled bool          // launch a second attempt for slow calls
+	HedgeDelay   time.Duration // wait before hedging; zero uses the observed p95 latency
+	CacheTTL     time.Duration // how long successful results are cached; zero uses the cache's TTL
 }
 
 // Aggregator handles parallel queries to multiple flight providers
@@ -33,48 +120,102 @@ type Aggregator struct {
 	providers   []providers.Provider
 	timeout     time.Duration
 	retryParams retry.Params
+	breakers    map[string]*circuitbreaker.Breaker // provider name -> circuit breaker
+	policies    map[string]ProviderPolicy          // provider name -> timeout and retry overrides
+	stats       map[string]*providerStats          // provider name -> latency and hedge metrics
+	cache       *cache.Cache                       // per-provider result cache; nil disables caching
+	refreshing  sync.Map                           // cache key -> struct{} for stale entries being refreshed
+
+	// softDeadline is how long SearchAll waits before returning partial results; zero waits for all providers
+	softDeadline time.Duration
 }
 
 // NewAggregator creates a new aggregator with the given providers and timeout
-func NewAggregator(providerList []providers.Provider, timeout time.Duration, retryParams retry.Params) *Aggregator {
+func NewAggregator(providerList []providers.Provider, timeout time.Duration, retryParams retry.Params, breakerParams circuitbreaker.Params) *Aggregator {
+	breakers := make(map[string]*circuitbreaker.Breaker, len(providerList))
+	stats := make(map[string]*providerStats, len(providerList))
+	for _, provider := range providerList {
+		breakers[provider.Name()] = circuitbreaker.New(breakerParams)
+		stats[provider.Name()] = &providerStats{}
+	}
+
 	return &Aggregator{
 		providers:   providerList,
 		timeout:     timeout,
 		retryParams: retryParams,
+		breakers:    breakers,
+		policies:    make(map[string]ProviderPolicy),
+		stats:       stats,
 	}
 }
 
+// SetProviderPolicy overrides the timeout and retry settings for a provider
+// Must be called before the aggregator starts serving searches
+func (a *Aggregator) SetProviderPolicy(providerName string, policy ProviderPolicy) {
+	a.policies[providerName] = policy
+}
+
+// SetSoftDeadline sets how long SearchAll waits before returning partial results
+// Must be called before the aggregator starts serving searches
+func (a *Aggregator) SetSoftDeadline(d time.Duration) {
+	a.softDeadline = d
+}
+
+// SetResultCache enables caching of each provider's results
+// Must be called before the aggregator starts serving searches
+func (a *Aggregator) SetResultCache(c *cache.Cache) {
+	a.cache = c
+}
+
+// policyFor returns the provider's policy, defaulting to the global timeout and retry settings
+func (a *Aggregator) policyFor(providerName string) ProviderPolicy {
+	if policy, ok := a.policies[providerName]; ok {
+		return policy
+	}
+	return ProviderPolicy{Timeout: a.timeout, Retry: a.retryParams}
+}
+
 // SearchAll queries all providers in parallel and aggregates results
 func (a *Aggregator) SearchAll(ctx context.Context, req models.SearchRequest) (*AggregatedResults, error) {
-	startTime := time.Now()
+	return a.search(ctx, req, a.softDeadline, nil)
+}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(ctx, a.timeout)
-	defer cancel()
+// SearchAllStream queries all providers in parallel like SearchAll, calling onResult
+// with each provider's result as soon as it arrives. It waits for every provider
+// regardless of the soft deadline. onResult is called from the caller's goroutine.
+func (a *Aggregator) SearchAllStream(ctx context.Context, req models.SearchRequest, onResult func(ProviderResult)) (*AggregatedResults, error) {
+	return a.search(ctx, req, 0, onResult)
+}
 
-	// Filter providers based on airline filter if specified
-	providersToQuery := a.providers
-	if req.Filters != nil && len(req.Filters.Airlines) > 0 {
-		// Create a map for faster lookup (case-insensitive)
-		airlineFilter := make(map[string]bool)
-		for _, airline := range req.Filters.Airlines {
-			airlineFilter[strings.ToLower(airline)] = true
-		}
+// search fans out to the selected providers and collects their results
+// A softDeadline of zero waits for all providers; onResult may be nil
+func (a *Aggregator) search(ctx context.Context, req models.SearchRequest, softDeadline time.Duration, onResult func(ProviderResult)) (*AggregatedResults, error) {
+	startTime := time.Now()
 
-		// Filter providers that match the airline filter
-		filteredProviders := make([]providers.Provider, 0)
-		for _, provider := range a.providers {
-			if airlineFilter[strings.ToLower(provider.Name())] {
-				filteredProviders = append(filteredProviders, provider)
-			}
-		}
+	// Only query providers that sell the requested airlines and serve the route
+	providersToQuery, skipped := a.selectProviders(req)
+	if len(providersToQuery) == 0 {
+		return &AggregatedResults{
+			Flights:         make([]models.Flight, 0),
+			ProviderResults: make(map[string]int),
+			ProviderErrors:  make(map[string]string),
+			ProviderSkipped: skipped,
+			TotalDuration:   time.Since(startTime),
+		}, noProvidersError(req, skipped)
+	}
 
-		// Only update if we found matching providers
-		if len(filteredProviders) > 0 {
-			providersToQuery = filteredProviders
-		}
+	// Providers still pending at the soft deadline keep running after the caller
+	// returns to cache their results, so detach provider queries from its
+	// cancellation when a soft deadline is configured
+	queryCtx := ctx
+	if softDeadline > 0 {
+		queryCtx = context.WithoutCancel(ctx)
 	}
 
+	// Create context bounded by the slowest provider's timeout
+	// Each provider is further bounded by its own timeout in queryProvider
+	queryCtx, cancel := context.WithTimeout(queryCtx, a.maxTimeout(providersToQuery))
+
 	// Create channels for communication
 	results := make(chan ProviderResult, len(providersToQuery))
 	var wg sync.WaitGroup
@@ -84,7 +225,27 @@ func (a *Aggregator) SearchAll(ctx context.Context, req models.SearchRequest) (*
 		wg.Add(1)
 		go func(p providers.Provider) {
 			defer wg.Done()
-			a.queryProvider(ctx, p, req, results)
+			if flights, freshness, found := a.cachedFlights(p, req); found {
+				stale := freshness.Stale()
+				if stale {
+					a.refreshInBackground(p, req)
+				}
+				result := ProviderResult{
+					Provider:  p.Name(),
+					Flights:   flights,
+					CacheHit:  true,
+					Stale:     stale,
+					FetchedAt: freshness.CachedAt,
+					ExpiresAt: freshness.FreshUntil,
+				}
+				// A cached empty answer is reported like the provider's own
+				if len(flights) == 0 {
+					result.Error = providers.ErrNoFlightsFound
+				}
+				results <- result
+				return
+			}
+			results <- a.queryProvider(queryCtx, p, req)
 		}(provider)
 	}
 
@@ -94,28 +255,204 @@ func (a *Aggregator) SearchAll(ctx context.Context, req models.SearchRequest) (*
 		close(results)
 	}()
 
-	// Fan-in: Collect results
-	aggregated := a.collectResults(results)
+	// Fan-in: Collect results until all providers answer or the soft deadline passes
+	received, done := receiveResults(ctx, results, softDeadline, onResult)
+	aggregated := a.collectResults(received)
+	aggregated.ProviderSkipped = skipped
 	aggregated.TotalDuration = time.Since(startTime)
 
+	if done {
+		cancel()
+	} else {
+		aggregated.ProviderPending = pendingProviders(providersToQuery, received)
+		log.Printf("Soft deadline passed after %v, returning partial results; pending providers: %s",
+			aggregated.TotalDuration, strings.Join(aggregated.ProviderPending, ", "))
+
+		// Pending providers cache their own results in queryProvider; release the
+		// query context once they have all finished
+		go func() {
+			defer cancel()
+			for range results {
+			}
+		}()
+	}
+
 	// Check if we got at least some results
 	if len(aggregated.Flights) == 0 {
-		return aggregated, fmt.Errorf("no flights found from any provider")
+		return aggregated, noResultsError(ctx, received, aggregated)
 	}
 
 	return aggregated, nil
 }
 
-// queryProvider queries a single provider and sends result to channel
-func (a *Aggregator) queryProvider(ctx context.Context, provider providers.Provider, req models.SearchRequest, results chan<- ProviderResult) {
+// noProvidersError explains why no provider was queried for the request
+func noProvidersError(req models.SearchRequest, skipped map[string]string) error {
+	route := providers.RouteKey(req.Origin, req.Destination)
+	for _, reason := range skipped {
+		if reason == SkipReasonAirline {
+			return &searchError{ErrNoProviders, fmt.Sprintf("no provider serving route %s sells the requested airlines", route)}
+		}
+	}
+	return &searchError{ErrNoProviders, fmt.Sprintf("no provider serves route %s", route)}
+}
+
+// noResultsError explains why a search that queried providers has no flights
+// Context errors are returned when the caller's context ended or every provider timed out.
+func noResultsError(ctx context.Context, received []ProviderResult, aggregated *AggregatedResults) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("search aborted before any provider returned flights: %w", err)
+	}
+	if len(aggregated.ProviderResults) > 0 || len(aggregated.ProviderPending) > 0 || len(received) == 0 {
+		return &searchError{ErrNoResults, "no flights found from any provider"}
+	}
+
+	// Providers report an empty search as providers.ErrNoFlightsFound
+	timedOut := true
+	for _, result := range received {
+		if errors.Is(result.Error, providers.ErrNoFlightsFound) {
+			return &searchError{ErrNoResults, "no flights found from any provider"}
+		}
+		timedOut = timedOut && (errors.Is(result.Error, providers.ErrProviderTimeout) || errors.Is(result.Error, context.DeadlineExceeded))
+	}
+	if timedOut {
+		return fmt.Errorf("all %d providers timed out: %w", len(received), context.DeadlineExceeded)
+	}
+	return &searchError{ErrAllProvidersFailed, fmt.Sprintf("all %d providers failed", len(received))}
+}
+
+// receiveResults reads provider results until the channel is closed (done = true)
+// or the soft deadline has passed and at least one flight has been received.
+// onResult, if set, is called with each result as it arrives.
+func receiveResults(ctx context.Context, results <-chan ProviderResult, softDeadline time.Duration, onResult func(ProviderResult)) ([]ProviderResult, bool) {
+	received := make([]ProviderResult, 0, cap(results))
+
+	// Without a soft deadline, providers share the caller's context and
+	// return on their own when it is cancelled
+	var deadline <-chan time.Time
+	var callerDone <-chan struct{}
+	if softDeadline > 0 {
+		timer := time.NewTimer(softDeadline)
+		defer timer.Stop()
+		deadline = timer.C
+		callerDone = ctx.Done()
+	}
+
+	deadlinePassed := false
+	hasFlights := false
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				return received, true
+			}
+			received = append(received, result)
+			hasFlights = hasFlights || (result.Error == nil && len(result.Flights) > 0)
+			if onResult != nil {
+				onResult(result)
+			}
+		case <-deadline:
+			deadlinePassed = true
+			deadline = nil
+		case <-callerDone:
+			// Caller gave up; pending providers still cache their results
+			return received, false
+		}
+
+		// Return partial results only once there is something to show
+		if deadlinePassed && hasFlights {
+			return received, false
+		}
+	}
+}
+
+// pendingProviders returns the queried providers that have not reported a result
+func pendingProviders(queried []providers.Provider, received []ProviderResult) []string {
+	answered := make(map[string]bool, len(received))
+	for _, result := range received {
+		answered[result.Provider] = true
+	}
+
+	pending := make([]string, 0)
+	for _, provider := range queried {
+		if !answered[provider.Name()] {
+			pending = append(pending, provider.Name())
+		}
+	}
+	return pending
+}
+
+// selectProviders returns the providers to query for a request and the
+// providers skipped with the reason. Providers are skipped when they do not
+// serve the origin/destination pair, or when an airline filter is specified
+// and they sell none of the requested carriers (matched by IATA code or name,
+// case-insensitive).
+func (a *Aggregator) selectProviders(req models.SearchRequest) ([]providers.Provider, map[string]string) {
+	// Create a map for faster lookup (case-insensitive)
+	var airlineFilter map[string]bool
+	if req.Filters != nil && len(req.Filters.Airlines) > 0 {
+		airlineFilter = make(map[string]bool)
+		for _, airline := range req.Filters.Airlines {
+			airlineFilter[strings.ToLower(strings.TrimSpace(airline))] = true
+		}
+	}
+
+	selected := make([]providers.Provider, 0, len(a.providers))
+	skipped := make(map[string]string)
+
+	for _, provider := range a.providers {
+		if !provider.ServesRoute(req.Origin, req.Destination) {
+			skipped[provider.Name()] = SkipReasonRoute
+			continue
+		}
+
+		if airlineFilter != nil && !sellsAnyCarrier(provider, airlineFilter) {
+			skipped[provider.Name()] = SkipReasonAirline
+			continue
+		}
+
+		selected = append(selected, provider)
+	}
+
+	return selected, skipped
+}
+
+// sellsAnyCarrier checks if the provider sells one of the carriers in the filter set
+func sellsAnyCarrier(provider providers.Provider, airlineFilter map[string]bool) bool {
+	for _, carrier := range provider.Carriers() {
+		if airlineFilter[strings.ToLower(carrier.Code)] || airlineFilter[strings.ToLower(carrier.Name)] {
+			return true
+		}
+	}
+	return false
+}
+
+// maxTimeout returns the longest timeout among the given providers
+func (a *Aggregator) maxTimeout(providerList []providers.Provider) time.Duration {
+	longest := a.timeout
+	for _, provider := range providerList {
+		if timeout := a.policyFor(provider.Name()).Timeout; timeout > longest {
+			longest = timeout
+		}
+	}
+	return longest
+}
+
+// queryProvider queries a single provider and caches successful results, including
+// valid empty answers
+func (a *Aggregator) queryProvider(ctx context.Context, provider providers.Provider, req models.SearchRequest) ProviderResult {
 	providerStart := time.Now()
+	policy := a.policyFor(provider.Name())
+
+	// Bound this provider by its own timeout
+	ctx, cancel := context.WithTimeout(ctx, policy.Timeout)
+	defer cancel()
 
 	var flights []models.Flight
 	var err error
 
 	// Execute search with retry logic and exponential backoff
-	retryErr := retry.RetryWithCheck(ctx, a.retryParams, func() (error, bool) {
-		flights, err = provider.Search(ctx, req)
+	retryErr := retry.RetryWithCheck(ctx, policy.Retry, func() (error, bool) {
+		flights, err = a.hedgedSearch(ctx, provider, req, policy)
 
 		// Check if error is retryable
 		if err != nil {
@@ -131,13 +468,119 @@ func (a *Aggregator) queryProvider(ctx context.Context, provider providers.Provi
 		err = retryErr
 	}
 
-	// Send result to channel
-	results <- ProviderResult{
-		Provider: provider.Name(),
-		Flights:  flights,
-		Error:    err,
-		Duration: time.Since(providerStart),
+	result := ProviderResult{
+		Provider:  provider.Name(),
+		Flights:   flights,
+		Error:     err,
+		Duration:  time.Since(providerStart),
+		FetchedAt: time.Now(),
+	}
+
+	// Only successful results are cached so failed providers are queried again
+	// "No flights found" is a valid answer, cached as an empty list so the provider
+	// is not re-queried on every search of the route
+	if err == nil || errors.Is(err, providers.ErrNoFlightsFound) {
+		if flights == nil {
+			flights = []models.Flight{}
+		}
+		if freshness, cached := a.cacheFlights(provider, req, flights, policy.CacheTTL); cached {
+			result.FetchedAt = freshness.CachedAt
+			result.ExpiresAt = freshness.FreshUntil
+		}
+	}
+
+	return result
+}
+
+// refreshInBackground re-queries a provider whose cached results are stale
+// At most one refresh runs per cache entry; open circuits make it fail fast.
+func (a *Aggregator) refreshInBackground(provider providers.Provider, req models.SearchRequest) {
+	key := a.cache.GenerateKey(provider.Name(), req)
+	if _, running := a.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	go func() {
+		defer a.refreshing.Delete(key)
+
+		result := a.queryProvider(context.Background(), provider, req)
+		err := result.Error
+		if errors.Is(err, providers.ErrNoFlightsFound) {
+			err = nil // cached as an empty answer
+		}
+		a.cache.RecordRefresh(err)
+		if err != nil {
+			log.Printf("Background refresh of %s failed: %v", key, err)
+			return
+		}
+		log.Printf("Refreshed stale cache entry %s", key)
+	}()
+}
+
+// SelectProviders returns the providers a search for req queries
+func (a *Aggregator) SelectProviders(req models.SearchRequest) []providers.Provider {
+	selected, _ := a.selectProviders(req)
+	return selected
+}
+
+// NeedsRefresh reports whether the provider's cached results for req are missing or
+// turn stale before freshUntil. Without a result cache there is nothing to refresh.
+func (a *Aggregator) NeedsRefresh(provider providers.Provider, req models.SearchRequest, freshUntil time.Time) bool {
+	if a.cache == nil {
+		return false
+	}
+
+	// Peek so warm runs don't count as cache hits and misses
+	freshness, found := a.cache.Peek(a.cache.GenerateKey(provider.Name(), req))
+	return !found || freshness.FreshUntil.Before(freshUntil)
+}
+
+// RefreshProvider queries a provider for req, bypassing its cached results, and caches
+// the answer. A refresh already running for the same cache entry is not repeated.
+func (a *Aggregator) RefreshProvider(ctx context.Context, provider providers.Provider, req models.SearchRequest) error {
+	if a.cache != nil {
+		key := a.cache.GenerateKey(provider.Name(), req)
+		if _, running := a.refreshing.LoadOrStore(key, struct{}{}); running {
+			return nil
+		}
+		defer a.refreshing.Delete(key)
 	}
+
+	err := a.queryProvider(ctx, provider, req).Error
+	if errors.Is(err, providers.ErrNoFlightsFound) {
+		return nil // cached as an empty answer
+	}
+	return err
+}
+
+// searchWithBreaker calls provider.Search through the provider's circuit breaker
+// Open circuits fail fast with circuitbreaker.ErrOpen
+func (a *Aggregator) searchWithBreaker(ctx context.Context, provider providers.Provider, req models.SearchRequest) ([]models.Flight, error) {
+	breaker := a.breakers[provider.Name()]
+	if !breaker.Allow() {
+		return nil, fmt.Errorf("%s: %w", provider.Name(), circuitbreaker.ErrOpen)
+	}
+
+	callStart := time.Now()
+	flights, err := provider.Search(ctx, req)
+
+	// Cancelled and timed-out calls are the slow tail: their elapsed time is a lower
+	// bound of the provider's latency, and leaving them out would drag the p95 that
+	// triggers hedges down
+	a.stats[provider.Name()].recordLatency(time.Since(callStart))
+
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.Canceled):
+		// Cancelled by the caller (e.g. a hedge won), not a provider failure
+		breaker.RecordCancelled()
+	case err != nil && !errors.Is(err, providers.ErrNoFlightsFound):
+		breaker.RecordFailure()
+	default:
+		// "No flights found" is a valid response, not a provider failure
+		breaker.RecordSuccess()
+	}
+
+	return flights, err
 }
 
 // isRetryableError determines if an error should trigger a retry
@@ -152,6 +595,11 @@ func isRetryableError(err error) bool {
 		return false
 	}
 
+	// Don't retry while the circuit is open
+	if errors.Is(err, circuitbreaker.ErrOpen) {
+		return false
+	}
+
 	// Retry for timeout, unavailable, and invalid response errors
 	if errors.Is(err, providers.ErrProviderTimeout) ||
 		errors.Is(err, providers.ErrProviderUnavailable) ||
@@ -163,34 +611,105 @@ func isRetryableError(err error) bool {
 	return true
 }
 
-// collectResults gathers all provider results from the channel
-func (a *Aggregator) collectResults(results <-chan ProviderResult) *AggregatedResults {
+// ErrorMessage formats a provider error for reporting
+// Fast-failed providers are reported with the stable CircuitOpenError marker
+func ErrorMessage(err error) string {
+	if errors.Is(err, circuitbreaker.ErrOpen) {
+		return CircuitOpenError
+	}
+	return err.Error()
+}
+
+// collectResults combines provider results into aggregated results
+func (a *Aggregator) collectResults(results []ProviderResult) *AggregatedResults {
 	aggregated := &AggregatedResults{
 		Flights:         make([]models.Flight, 0),
 		ProviderResults: make(map[string]int),
 		ProviderErrors:  make(map[string]string),
+		ProviderCache:   make(map[string]string),
 	}
 
-	// Collect from channel until closed
-	for result := range results {
-		if result.Error != nil {
+	uncached := false
+	for _, result := range results {
+		if result.Stale {
+			aggregated.ProviderCache[result.Provider] = CacheStatusStale
+		} else if result.CacheHit {
+			aggregated.ProviderCache[result.Provider] = CacheStatusHit
+		} else {
+			aggregated.ProviderCache[result.Provider] = CacheStatusMiss
+		}
+
+		// "No flights found" is a valid answer with no flights, not a provider failure
+		if result.Error != nil && !errors.Is(result.Error, providers.ErrNoFlightsFound) {
 			// Track provider errors
-			aggregated.ProviderErrors[result.Provider] = result.Error.Error()
+			aggregated.ProviderErrors[result.Provider] = ErrorMessage(result.Error)
 		} else {
 			// Add successful results
 			aggregated.Flights = append(aggregated.Flights, result.Flights...)
 			aggregated.ProviderResults[result.Provider] = len(result.Flights)
+
+			// Track the oldest fetch and the earliest expiry
+			if !result.FetchedAt.IsZero() && (aggregated.FetchedAt.IsZero() || result.FetchedAt.Before(aggregated.FetchedAt)) {
+				aggregated.FetchedAt = result.FetchedAt
+			}
+			if result.ExpiresAt.IsZero() {
+				uncached = true
+			} else if aggregated.ExpiresAt.IsZero() || result.ExpiresAt.Before(aggregated.ExpiresAt) {
+				aggregated.ExpiresAt = result.ExpiresAt
+			}
 		}
 	}
+	if uncached {
+		aggregated.ExpiresAt = time.Time{}
+	}
+
+	// Merge the same physical flight sold through multiple providers
+	aggregated.Flights, aggregated.DuplicatesMerged = dedupeFlights(aggregated.Flights)
 
 	return aggregated
 }
 
+// cachedFlights returns the provider's cached results for the request, if any,
+// including results past their TTL but within the cache's stale window
+func (a *Aggregator) cachedFlights(provider providers.Provider, req models.SearchRequest) (flights []models.Flight, freshness cache.Freshness, found bool) {
+	if a.cache == nil {
+		return nil, cache.Freshness{}, false
+	}
+
+	freshness, found = a.cache.GetStale(a.cache.GenerateKey(provider.Name(), req), &flights)
+	return flights, freshness, found
+}
+
+// cacheFlights caches the provider's results for the request
+func (a *Aggregator) cacheFlights(provider providers.Provider, req models.SearchRequest, flights []models.Flight, ttl time.Duration) (cache.Freshness, bool) {
+	if a.cache == nil {
+		return cache.Freshness{}, false
+	}
+
+	return a.cache.SetWithTTL(a.cache.GenerateKey(provider.Name(), req), flights, ttl)
+}
+
 // GetProviders returns the list of providers
 func (a *Aggregator) GetProviders() []providers.Provider {
 	return a.providers
 }
 
+// CircuitState returns the circuit breaker state for a provider
+func (a *Aggregator) CircuitState(providerName string) circuitbreaker.State {
+	if breaker, ok := a.breakers[providerName]; ok {
+		return breaker.State()
+	}
+	return circuitbreaker.StateClosed
+}
+
+// Metrics returns latency and hedge metrics for a provider
+func (a *Aggregator) Metrics(providerName string) ProviderMetrics {
+	if stats, ok := a.stats[providerName]; ok {
+		return stats.metrics()
+	}
+	return ProviderMetrics{}
+}
+
 // GetTimeout returns the configured timeout
 func (a *Aggregator) GetTimeout() time.Duration {
 	return a.timeout
@@ -0,0 +1,60 @@
+package aggregator
+
+import (
+	"flight-aggregator/internal/models"
+	"fmt"
+	"sort"
+)
+
+// dedupeFlights merges offers for the same physical flight sold by multiple providers.
+// Flights are grouped by operating carrier, flight number and departure time; the
+// cheapest offer becomes the primary flight and the others are listed as alternates.
+// Returns the merged flights and the number of duplicates removed.
+func dedupeFlights(flights []models.Flight) ([]models.Flight, int) {
+	groups := make(map[string][]models.Flight)
+	order := make([]string, 0)
+
+	for _, flight := range flights {
+		key := flightKey(flight)
+		if _, exists := groups[key]; !exists {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], flight)
+	}
+
+	merged := make([]models.Flight, 0, len(order))
+	duplicates := 0
+
+	for _, key := range order {
+		offers := groups[key]
+		if len(offers) == 1 {
+			merged = append(merged, offers[0])
+			continue
+		}
+
+		// Cheapest offer first
+		sort.SliceStable(offers, func(i, j int) bool {
+			return offers[i].Price.Amount < offers[j].Price.Amount
+		})
+
+		primary := offers[0]
+		primary.Alternates = make([]models.FlightOffer, 0, len(offers)-1)
+		for _, alt := range offers[1:] {
+			primary.Alternates = append(primary.Alternates, models.FlightOffer{
+				ID:       alt.ID,
+				Provider: alt.Provider,
+				Price:    alt.Price,
+			})
+		}
+
+		merged = append(merged, primary)
+		duplicates += len(offers) - 1
+	}
+
+	return merged, duplicates
+}
+
+// flightKey identifies a physical flight by operating carrier, flight number and departure time
+func flightKey(flight models.Flight) string {
+	return fmt.Sprintf("%s|%s|%d", flight.Airline.Code, flight.FlightNumber, flight.Departure.Timestamp)
+}
@@ -0,0 +1,79 @@
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"flight-aggregator/internal/models"
+	"flight-aggregator/internal/providers"
+	"log"
+	"time"
+)
+
+// searchAttempt is the outcome of a single provider.Search call
+type searchAttempt struct {
+	flights []models.Flight
+	err     error
+	hedge   bool
+}
+
+// hedgedSearch calls the provider and, if hedging is enabled and the call has not
+// returned within the hedge delay, launches a second concurrent attempt. The first
+// successful attempt wins and the other is cancelled. The hedge delay is the
+// configured delay, or the provider's observed p95 latency when none is configured.
+func (a *Aggregator) hedgedSearch(ctx context.Context, provider providers.Provider, req models.SearchRequest, policy ProviderPolicy) ([]models.Flight, error) {
+	if !policy.HedgeEnabled {
+		return a.searchWithBreaker(ctx, provider, req)
+	}
+
+	stats := a.stats[provider.Name()]
+	delay := policy.HedgeDelay
+	if delay <= 0 {
+		p95, ok := stats.p95()
+		if !ok {
+			// Not enough latency samples yet
+			return a.searchWithBreaker(ctx, provider, req)
+		}
+		delay = p95
+	}
+
+	// Cancel the losing attempt once a winner returns
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered so the losing attempt never blocks after we return
+	attempts := make(chan searchAttempt, 2)
+	launch := func(hedge bool) {
+		go func() {
+			flights, err := a.searchWithBreaker(ctx, provider, req)
+			attempts <- searchAttempt{flights: flights, err: err, hedge: hedge}
+		}()
+	}
+
+	launch(false)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case attempt := <-attempts:
+		return attempt.flights, attempt.err
+	case <-timer.C:
+		log.Printf("provider %s: no response after %v, launching hedge request", provider.Name(), delay)
+		stats.recordHedge()
+		launch(true)
+	}
+
+	// Take the first successful attempt; if both fail, return the last error
+	var last searchAttempt
+	for i := 0; i < 2; i++ {
+		last = <-attempts
+		if last.err == nil || errors.Is(last.err, providers.ErrNoFlightsFound) {
+			if last.hedge {
+				stats.recordHedgeWin()
+			}
+			return last.flights, last.err
+		}
+	}
+
+	return last.flights, last.err
+}
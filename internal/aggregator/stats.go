@@ -0,0 +1,90 @@
+package aggregator
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// latencySamples is the number of recent call latencies kept per provider
+	latencySamples = 100
+
+	// minLatencySamples is the number of samples needed before p95 is trusted
+	minLatencySamples = 20
+)
+
+// ProviderMetrics contains runtime metrics for a single provider
+type ProviderMetrics struct {
+	P95Latency time.Duration // zero until enough samples are collected
+	Hedges     int64         // hedge attempts launched
+	HedgeWins  int64         // hedge attempts that returned first
+}
+
+// providerStats tracks recent latencies and hedge counters for a provider
+type providerStats struct {
+	mu        sync.Mutex
+	latencies []time.Duration // ring buffer of recent call latencies
+	next      int
+	hedges    int64
+	hedgeWins int64
+}
+
+// recordLatency adds a call latency to the ring buffer
+// For cancelled and failed calls it is the time until the call returned.
+func (s *providerStats) recordLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.latencies) < latencySamples {
+		s.latencies = append(s.latencies, d)
+		return
+	}
+	s.latencies[s.next] = d
+	s.next = (s.next + 1) % latencySamples
+}
+
+// p95 returns the 95th percentile latency
+// Returns (0, false) until minLatencySamples have been recorded
+func (s *providerStats) p95() (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.latencies) < minLatencySamples {
+		return 0, false
+	}
+
+	sorted := make([]time.Duration, len(s.latencies))
+	copy(sorted, s.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return sorted[(len(sorted)*95)/100], true
+}
+
+// recordHedge counts a launched hedge attempt
+func (s *providerStats) recordHedge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hedges++
+}
+
+// recordHedgeWin counts a hedge attempt that returned before the primary
+func (s *providerStats) recordHedgeWin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hedgeWins++
+}
+
+// metrics returns a snapshot of the provider metrics
+func (s *providerStats) metrics() ProviderMetrics {
+	p95, _ := s.p95()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return ProviderMetrics{
+		P95Latency: p95,
+		Hedges:     s.hedges,
+		HedgeWins:  s.hedgeWins,
+	}
+}
@@ -1,68 +1,102 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flight-aggregator/internal/aggregator"
 	"flight-aggregator/internal/models"
 	"flight-aggregator/internal/service"
+	"flight-aggregator/internal/validator"
+	"fmt"
 	"log"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 )
 
 // Handler handles HTTP requests
 type Handler struct {
-	searchService *service.SearchService
+	searchService      *service.SearchService
+	sessionIdleTimeout time.Duration
+	webSocketOrigins   map[string]bool // cross-origin pages allowed to open live search sessions
 }
 
 // NewHandler creates a new API handler
-func NewHandler(searchService *service.SearchService) *Handler {
+// sessionIdleTimeout closes idle WebSocket search sessions (defaults to 5 minutes)
+func NewHandler(searchService *service.SearchService, sessionIdleTimeout time.Duration) *Handler {
+	if sessionIdleTimeout <= 0 {
+		sessionIdleTimeout = defaultSessionIdleTimeout
+	}
+
 	return &Handler{
-		searchService: searchService,
+		searchService:      searchService,
+		sessionIdleTimeout: sessionIdleTimeout,
 	}
 }
 
-// Search handles flight search requests
+// Search handles flight search requests, given as a JSON body (POST) or as
+// query parameters (GET) so that searches can be bookmarked, shared and cached
 func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
-	var req models.SearchRequest
-
-	// Decode request body
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Failed to decode request body: %v", err)
-		respondWithErrorDetailed(w, http.StatusBadRequest, "Invalid request body", err.Error())
+	req, err := decodeSearchRequest(r)
+	if err != nil {
+		log.Printf("Failed to decode search request: %v", err)
+		respondWithError(w, decodeError(err))
 		return
 	}
 
 	// Perform search
 	response, err := h.searchService.Search(r.Context(), req)
 	if err != nil {
-		// Determine appropriate error code based on error type
-		statusCode := http.StatusInternalServerError
-		errorType := "Internal server error"
-
-		errMsg := err.Error()
-
-		// Check for validation errors (common patterns)
-		if strings.Contains(errMsg, "invalid") ||
-			strings.Contains(errMsg, "required") ||
-			strings.Contains(errMsg, "must be") {
-			statusCode = http.StatusBadRequest
-			errorType = "Validation error"
-		}
-
-		// Check for timeout errors
-		if strings.Contains(errMsg, "timeout") || strings.Contains(errMsg, "context deadline exceeded") {
-			statusCode = http.StatusGatewayTimeout
-			errorType = "Request timeout"
+		if r.Method == http.MethodGet {
+			err = queryParamError(err, r.URL.Query())
 		}
-
 		log.Printf("Search failed: %v", err)
-		respondWithErrorDetailed(w, statusCode, errorType, err.Error())
+		respondWithError(w, classifyError(err))
 		return
 	}
 
+	setCacheHeaders(w, response)
 	respondWithJSON(w, http.StatusOK, response)
 }
 
+// SearchStream handles flight search requests as Server-Sent Events.
+// A "provider" event is sent for each provider as soon as it answers, followed
+// by a "result" event with the merged, filtered and sorted response, or an
+// "error" event if the search fails.
+func (h *Handler) SearchStream(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeSearchRequest(r)
+	if err != nil {
+		log.Printf("Failed to decode search request: %v", err)
+		respondWithError(w, decodeError(err))
+		return
+	}
+
+	sse, ok := newSSEWriter(w)
+	if !ok {
+		respondWithErrorDetailed(w, http.StatusInternalServerError, models.ErrorCodeInternal, "Internal server error", "streaming not supported")
+		return
+	}
+
+	response, err := h.searchService.SearchStream(r.Context(), req, func(update models.ProviderUpdate) {
+		if err := sse.send("provider", update); err != nil {
+			log.Printf("Failed to send provider event: %v", err)
+		}
+	})
+	if err != nil {
+		if r.Method == http.MethodGet {
+			err = queryParamError(err, r.URL.Query())
+		}
+		log.Printf("Streaming search failed: %v", err)
+		sse.send("error", classifyError(err))
+		return
+	}
+
+	if err := sse.send("result", response); err != nil {
+		log.Printf("Failed to send result event: %v", err)
+	}
+}
+
 // Health checks if the service is healthy
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, map[string]string{
@@ -70,6 +104,19 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// OpenAPI serves the OpenAPI 3 specification of the API
+func (h *Handler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	spec, _, err := openAPISpec()
+	if err != nil {
+		log.Printf("Failed to build OpenAPI spec: %v", err)
+		respondWithErrorDetailed(w, http.StatusInternalServerError, models.ErrorCodeInternal, "Internal server error", "OpenAPI spec unavailable")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(spec)
+}
+
 // ListProviders lists all available providers and their status
 func (h *Handler) ListProviders(w http.ResponseWriter, r *http.Request) {
 	providers := h.searchService.GetProviders()
@@ -78,13 +125,153 @@ func (h *Handler) ListProviders(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// CacheStats returns the cache statistics
+func (h *Handler) CacheStats(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.searchService.CacheStats())
+}
+
+// InvalidateCache purges cached provider results matching the query selectors
+func (h *Handler) InvalidateCache(w http.ResponseWriter, r *http.Request) {
+	sel, err := parseCacheSelector(r.URL.Query())
+	if err != nil {
+		respondWithError(w, decodeError(err))
+		return
+	}
+
+	deleted := h.searchService.InvalidateCache(sel)
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"deleted": deleted,
+	})
+}
+
+// StartWarmer starts the cache warmer
+func (h *Handler) StartWarmer(w http.ResponseWriter, r *http.Request) {
+	warmer := h.searchService.Warmer()
+	if err := warmer.Start(); err != nil {
+		respondWithErrorDetailed(w, http.StatusConflict, models.ErrorCodeConflict, "Conflict", err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, warmer.Status())
+}
+
+// StopWarmer stops the cache warmer
+func (h *Handler) StopWarmer(w http.ResponseWriter, r *http.Request) {
+	warmer := h.searchService.Warmer()
+	if err := warmer.Stop(); err != nil {
+		respondWithErrorDetailed(w, http.StatusConflict, models.ErrorCodeConflict, "Conflict", err.Error())
+		return
+	}
+	respondWithJSON(w, http.StatusOK, warmer.Status())
+}
+
+// WarmerStatus returns the cache warmer's state and last run
+func (h *Handler) WarmerStatus(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, h.searchService.Warmer().Status())
+}
+
 // Helper functions
-func respondWithErrorDetailed(w http.ResponseWriter, code int, errorType string, message string) {
-	respondWithJSON(w, code, models.ErrorResponse{
+
+// classifyError maps an error to its error response: the HTTP status, a stable
+// error code and, for validation errors, the offending field
+func classifyError(err error) models.ErrorResponse {
+	if validationErrs, ok := validator.AsValidationErrors(err); ok {
+		return validationErrorResponse(validationErrs)
+	}
+
+	switch {
+	case errors.Is(err, aggregator.ErrNoProviders):
+		return newErrorResponse(http.StatusNotFound, models.ErrorCodeNoProviders, "No providers", err.Error())
+	case errors.Is(err, aggregator.ErrNoResults):
+		return newErrorResponse(http.StatusNotFound, models.ErrorCodeNoResults, "No results", err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return newErrorResponse(http.StatusGatewayTimeout, models.ErrorCodeTimeout, "Request timeout", err.Error())
+	case errors.Is(err, aggregator.ErrAllProvidersFailed):
+		return newErrorResponse(http.StatusBadGateway, models.ErrorCodeAllProvidersFailed, "Providers unavailable", err.Error())
+	case errors.Is(err, service.ErrSessionNotFound):
+		return newErrorResponse(http.StatusNotFound, models.ErrorCodeSessionNotFound, "Session expired", err.Error())
+	default:
+		return newErrorResponse(http.StatusInternalServerError, models.ErrorCodeInternal, "Internal server error", err.Error())
+	}
+}
+
+// setCacheHeaders sets Age and Cache-Control from the freshness of the response's legs
+// Age is the age of the oldest provider results and max-age their lifetime, so HTTP
+// caches treat the response as fresh until the earliest results go stale. Responses
+// with stale, pending, failed or uncached provider results are not cacheable.
+func setCacheHeaders(w http.ResponseWriter, response *models.SearchResponse) {
+	legs := []*models.SearchMetadata{&response.Metadata}
+	if response.ReturnMetadata != nil {
+		legs = append(legs, response.ReturnMetadata)
+	}
+
+	var cachedAt, expiresAt time.Time
+	cacheable := true
+	for _, metadata := range legs {
+		if metadata.CachedAt != nil && (cachedAt.IsZero() || metadata.CachedAt.Before(cachedAt)) {
+			cachedAt = *metadata.CachedAt
+		}
+		if metadata.ExpiresAt == nil || metadata.Stale || metadata.ProvidersPending > 0 || metadata.ProvidersFailed > 0 {
+			cacheable = false
+		} else if expiresAt.IsZero() || metadata.ExpiresAt.Before(expiresAt) {
+			expiresAt = *metadata.ExpiresAt
+		}
+	}
+
+	if cachedAt.IsZero() {
+		w.Header().Set("Cache-Control", "no-cache")
+		return
+	}
+
+	age := int(time.Since(cachedAt).Seconds())
+	w.Header().Set("Age", strconv.Itoa(age))
+
+	lifetime := int(expiresAt.Sub(cachedAt).Seconds())
+	if !cacheable || lifetime <= age {
+		w.Header().Set("Cache-Control", "no-cache")
+		return
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", lifetime))
+}
+
+// validationErrorResponse lists every invalid field of a request
+func validationErrorResponse(validationErrs validator.ValidationErrors) models.ErrorResponse {
+	response := newErrorResponse(http.StatusBadRequest, models.ErrorCodeValidation, "Validation error", validationErrs.Error())
+	response.Field = validationErrs[0].Field
+	response.Errors = make([]models.FieldError, len(validationErrs))
+	for i, validationErr := range validationErrs {
+		response.Errors[i] = models.FieldError{
+			Field:   validationErr.Field,
+			Code:    validationErr.Code,
+			Message: validationErr.Message,
+		}
+	}
+	return response
+}
+
+// decodeError maps a request decoding error to its error response
+// Query parameter errors are validation errors naming the parameter.
+func decodeError(err error) models.ErrorResponse {
+	if validationErrs, ok := validator.AsValidationErrors(err); ok {
+		return validationErrorResponse(validationErrs)
+	}
+	return newErrorResponse(http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid request", err.Error())
+}
+
+func newErrorResponse(status int, code string, errorType string, message string) models.ErrorResponse {
+	return models.ErrorResponse{
 		Error:   errorType,
-		Message: message,
 		Code:    code,
-	})
+		Message: message,
+		Status:  status,
+	}
+}
+
+func respondWithError(w http.ResponseWriter, response models.ErrorResponse) {
+	respondWithJSON(w, response.Status, response)
+}
+
+func respondWithErrorDetailed(w http.ResponseWriter, status int, code string, errorType string, message string) {
+	respondWithError(w, newErrorResponse(status, code, errorType, message))
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
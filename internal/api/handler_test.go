@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"flight-aggregator/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSearchWithProviderWithoutFlightsIsCacheable(t *testing.T) {
+	// Lion Air claims CGK-SUB but has no flights on it; Garuda has one
+	cfg := newTestConfig("20ms", "5s", 0)
+	lionAir := cfg.Provider.Providers["lionair"]
+	lionAir.Routes = []string{"CGK-DPS", "CGK-SUB"}
+	cfg.Provider.Providers["lionair"] = lionAir
+	router := newTestRouter(t, cfg, testAdminKey)
+
+	search := func() (*httptest.ResponseRecorder, models.SearchResponse) {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/search?origin=CGK&destination=SUB&departureDate=2025-12-15&passengers=1&cabinClass=economy", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("search returned %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var response models.SearchResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return rec, response
+	}
+
+	_, first := search()
+	if got := first.Metadata.ProviderResults["Lion Air"]; got != 0 {
+		t.Errorf("Lion Air results = %d, want 0", got)
+	}
+	if first.Metadata.ProvidersFailed != 0 || len(first.Metadata.ProviderErrors) != 0 {
+		t.Errorf("providers_failed = %d, provider_errors = %v; want no failures", first.Metadata.ProvidersFailed, first.Metadata.ProviderErrors)
+	}
+	if first.Metadata.ProvidersSucceeded != 2 {
+		t.Errorf("providers_succeeded = %d, want 2", first.Metadata.ProvidersSucceeded)
+	}
+
+	// Both answers, including the empty one, are now served from the cache
+	rec, second := search()
+	if !second.Metadata.CacheHit || second.Metadata.ProviderCache["Lion Air"] != "hit" {
+		t.Errorf("cache_hit = %v, provider_cache = %v; want every provider served from the cache", second.Metadata.CacheHit, second.Metadata.ProviderCache)
+	}
+	if cacheControl := rec.Header().Get("Cache-Control"); !strings.HasPrefix(cacheControl, "public, max-age=") {
+		t.Errorf("Cache-Control = %q, want public, max-age=N", cacheControl)
+	}
+}
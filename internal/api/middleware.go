@@ -1,12 +1,18 @@
 package api
 
 import (
-	"encoding/json"
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"flight-aggregator/internal/models"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/gorilla/mux"
 	"golang.org/x/time/rate"
 )
 
@@ -21,6 +27,40 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush supports streaming responses (e.g. Server-Sent Events)
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack supports connection upgrades (e.g. WebSocket)
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+// recordingResponseWriter keeps a copy of the response body
+type recordingResponseWriter struct {
+	responseWriter
+	body     bytes.Buffer
+	hijacked bool
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Hijack supports connection upgrades, whose traffic is not recorded
+func (rw *recordingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	rw.hijacked = true
+	return rw.responseWriter.Hijack()
+}
+
 // LoggingMiddleware logs HTTP requests with status codes
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -46,11 +86,7 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 		defer func() {
 			if err := recover(); err != nil {
 				log.Printf("Panic recovered: %v", err)
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusInternalServerError)
-				json.NewEncoder(w).Encode(map[string]string{
-					"error": "Internal server error",
-				})
+				respondWithErrorDetailed(w, http.StatusInternalServerError, models.ErrorCodeInternal, "Internal server error", "")
 			}
 		}()
 
@@ -58,6 +94,27 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// AdminAuthMiddleware requires the admin API key in the X-Admin-Key header
+// Admin endpoints are disabled when no key is configured
+func AdminAuthMiddleware(apiKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey == "" {
+				respondWithErrorDetailed(w, http.StatusForbidden, models.ErrorCodeAdminDisabled, "Forbidden", "admin API is disabled; set admin.api_key or ADMIN_API_KEY")
+				return
+			}
+
+			provided := r.Header.Get("X-Admin-Key")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey)) != 1 {
+				respondWithErrorDetailed(w, http.StatusUnauthorized, models.ErrorCodeUnauthorized, "Unauthorized", "missing or invalid X-Admin-Key header")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // RateLimiter manages rate limiting for clients
 type RateLimiter struct {
 	limiters map[string]*rate.Limiter
@@ -99,14 +156,38 @@ func (rl *RateLimiter) RateLimitMiddleware(next http.Handler) http.Handler {
 
 		if !limiter.Allow() {
 			log.Printf("Rate limit exceeded for %s", clientIP)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusTooManyRequests)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Rate limit exceeded. Please try again later.",
-			})
+			respondWithErrorDetailed(w, http.StatusTooManyRequests, models.ErrorCodeRateLimited, "Rate limit exceeded", "Please try again later.")
 			return
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// ResponseValidationMiddleware checks every response against the OpenAPI spec and logs
+// violations: undocumented routes, statuses or content types, and JSON bodies or
+// Server-Sent Events that do not match their schema. Writes go straight to the client
+// while a copy of the body is kept and validated once the handler returns, so memory
+// grows with the whole response, for the full length of a Server-Sent Events stream;
+// it is meant for development and staging.
+func ResponseValidationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &recordingResponseWriter{responseWriter: responseWriter{ResponseWriter: w, statusCode: http.StatusOK}}
+
+		next.ServeHTTP(recorder, r)
+
+		route := mux.CurrentRoute(r)
+		if recorder.hijacked || route == nil {
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return
+		}
+
+		violations := validateResponse(template, r.Method, recorder.statusCode, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+		for _, violation := range violations {
+			log.Printf("OpenAPI violation: %s %s %d: %s", r.Method, template, recorder.statusCode, violation)
+		}
+	})
+}
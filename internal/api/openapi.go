@@ -0,0 +1,231 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// openAPIBasePath is the path prefix of the API routes, the server URL of the spec
+const openAPIBasePath = "/api/v1"
+
+// jsonObject is an object of the OpenAPI document
+type jsonObject = map[string]interface{}
+
+// openAPIJSON is the OpenAPI 3 specification of the API, served at /openapi.json
+// The file is checked in and generated from the request and response types by
+// TestOpenAPISpecUpToDate; regenerate it with
+// go test ./internal/api -run TestOpenAPISpecUpToDate -update
+//
+//go:embed openapi.json
+var openAPIJSON []byte
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  jsonObject // the document decoded, for response validation
+	openAPIErr  error
+)
+
+// openAPISpec returns the OpenAPI specification, as served and decoded
+func openAPISpec() ([]byte, jsonObject, error) {
+	openAPIOnce.Do(func() {
+		if err := json.Unmarshal(openAPIJSON, &openAPIDoc); err != nil {
+			openAPIErr = fmt.Errorf("decode OpenAPI spec: %w", err)
+		}
+	})
+	return openAPIJSON, openAPIDoc, openAPIErr
+}
+
+// CheckOpenAPISpec decodes the OpenAPI specification, so that a spec that cannot be
+// decoded fails at startup rather than on the first request for it
+func CheckOpenAPISpec() error {
+	_, _, err := openAPISpec()
+	return err
+}
+
+// validateResponse checks a response of a route against the spec
+// Returns a description of each violation: an undocumented route, method, status,
+// content type or event, or a body that does not match its schema.
+func validateResponse(route, method string, status int, contentType string, body []byte) []string {
+	_, doc, err := openAPISpec()
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	paths, _ := doc["paths"].(jsonObject)
+	item, ok := paths[strings.TrimPrefix(route, openAPIBasePath)].(jsonObject)
+	if !ok {
+		return []string{"undocumented route"}
+	}
+	operation, ok := item[strings.ToLower(method)].(jsonObject)
+	if !ok {
+		return []string{"undocumented method"}
+	}
+	responses, _ := operation["responses"].(jsonObject)
+	response, ok := responses[strconv.Itoa(status)].(jsonObject)
+	if !ok {
+		return []string{"undocumented status"}
+	}
+
+	content, _ := response["content"].(jsonObject)
+	if len(content) == 0 {
+		return nil
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	media, ok := content[strings.TrimSpace(mediaType)].(jsonObject)
+	if !ok {
+		return []string{fmt.Sprintf("undocumented content type %q", contentType)}
+	}
+
+	switch {
+	case strings.HasPrefix(contentType, "application/json"):
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			return []string{fmt.Sprintf("invalid JSON: %v", err)}
+		}
+		schema, _ := media["schema"].(jsonObject)
+		return validateSchema(doc, schema, value, "$")
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		events, _ := response["x-events"].(jsonObject)
+		return validateEvents(doc, events, body)
+	default:
+		return nil
+	}
+}
+
+// validateEvents checks each Server-Sent Event against the schema of its event name
+func validateEvents(doc, events jsonObject, body []byte) []string {
+	var violations []string
+	for _, block := range strings.Split(string(body), "\n\n") {
+		var event, data string
+		for _, line := range strings.Split(block, "\n") {
+			if name, ok := strings.CutPrefix(line, "event: "); ok {
+				event = name
+			} else if payload, ok := strings.CutPrefix(line, "data: "); ok {
+				data += payload
+			}
+		}
+		if event == "" {
+			continue
+		}
+
+		schema, ok := events[event].(jsonObject)
+		if !ok {
+			violations = append(violations, fmt.Sprintf("undocumented event %q", event))
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(data), &value); err != nil {
+			violations = append(violations, fmt.Sprintf("%s: invalid JSON: %v", event, err))
+			continue
+		}
+		violations = append(violations, validateSchema(doc, schema, value, event)...)
+	}
+	return violations
+}
+
+// validateSchema checks a decoded JSON value against a schema of the spec
+// Supports the keywords of the generated schemas: $ref, allOf, nullable, type, enum,
+// properties, required, additionalProperties and items. Properties missing from a
+// schema are reported too, as they are undocumented fields.
+func validateSchema(doc, schema jsonObject, value interface{}, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		components, _ := doc["components"].(jsonObject)
+		schemas, _ := components["schemas"].(jsonObject)
+		resolved, ok := schemas[strings.TrimPrefix(ref, "#/components/schemas/")].(jsonObject)
+		if !ok {
+			return []string{fmt.Sprintf("%s: unknown schema %s", path, ref)}
+		}
+		return validateSchema(doc, resolved, value, path)
+	}
+
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable || len(schema) == 0 {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: must not be null", path)}
+	}
+
+	var violations []string
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			subSchema, _ := sub.(jsonObject)
+			violations = append(violations, validateSchema(doc, subSchema, value, path)...)
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !slices.Contains(enum, value) {
+		violations = append(violations, fmt.Sprintf("%s: %v is not one of %v", path, value, enum))
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(jsonObject)
+		if !ok {
+			return append(violations, fmt.Sprintf("%s: expected object, got %s", path, jsonTypeOf(value)))
+		}
+
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				violations = append(violations, fmt.Sprintf("%s.%s: required property is missing", path, name))
+			}
+		}
+
+		properties, _ := schema["properties"].(jsonObject)
+		additional, hasAdditional := schema["additionalProperties"].(jsonObject)
+		for _, name := range slices.Sorted(maps.Keys(object)) {
+			propertyPath := path + "." + name
+			if property, ok := properties[name].(jsonObject); ok {
+				violations = append(violations, validateSchema(doc, property, object[name], propertyPath)...)
+			} else if hasAdditional {
+				violations = append(violations, validateSchema(doc, additional, object[name], propertyPath)...)
+			} else if properties != nil {
+				violations = append(violations, fmt.Sprintf("%s: undocumented property", propertyPath))
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return append(violations, fmt.Sprintf("%s: expected array, got %s", path, jsonTypeOf(value)))
+		}
+		items, _ := schema["items"].(jsonObject)
+		for i, item := range array {
+			violations = append(violations, validateSchema(doc, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "integer":
+		if number, ok := value.(float64); !ok || number != math.Trunc(number) {
+			violations = append(violations, fmt.Sprintf("%s: expected integer, got %s", path, jsonTypeOf(value)))
+		}
+	case "number", "string", "boolean":
+		if jsonTypeOf(value) != schema["type"] {
+			violations = append(violations, fmt.Sprintf("%s: expected %s, got %s", path, schema["type"], jsonTypeOf(value)))
+		}
+	}
+
+	return violations
+}
+
+// jsonTypeOf returns the JSON type of a decoded value
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,516 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"flight-aggregator/internal/cache"
+	"flight-aggregator/internal/models"
+	"flight-aggregator/internal/service"
+	"flight-aggregator/internal/validator"
+	"flight-aggregator/pkg/circuitbreaker"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "regenerate openapi.json")
+
+// Component names of unexported types
+var openAPIComponentNames = map[string]string{
+	"wsClientMessage": "SessionClientMessage",
+	"wsServerMessage": "SessionServerMessage",
+}
+
+// Allowed values of string fields, keyed by type and JSON field name
+var openAPIFieldEnums = map[string][]string{
+	"ErrorResponse.code": {
+		models.ErrorCodeInvalidRequest,
+		models.ErrorCodeValidation,
+		models.ErrorCodeNoProviders,
+		models.ErrorCodeNoResults,
+		models.ErrorCodeAllProvidersFailed,
+		models.ErrorCodeTimeout,
+		models.ErrorCodeRateLimited,
+		models.ErrorCodeSessionNotFound,
+		models.ErrorCodeUnauthorized,
+		models.ErrorCodeAdminDisabled,
+		models.ErrorCodeConflict,
+		models.ErrorCodeInternal,
+	},
+	"FieldError.code": {
+		validator.CodeRequired,
+		validator.CodeInvalidFormat,
+		validator.CodeInvalidValue,
+		validator.CodeOutOfRange,
+	},
+	"ProviderUpdate.leg": {models.LegOutbound, models.LegReturn},
+	"ProviderStatus.circuit_state": {
+		string(circuitbreaker.StateClosed),
+		string(circuitbreaker.StateOpen),
+		string(circuitbreaker.StateHalfOpen),
+	},
+	"WarmerStatus.source":  {"config", "popular"},
+	"wsClientMessage.type": {wsTypeSearch, wsTypeRefine},
+	"wsServerMessage.type": {wsTypeSession, wsTypeProvider, wsTypeResult, wsTypeError},
+}
+
+// Descriptions of fields whose format is not evident from their type
+var openAPIFieldDescriptions = map[string]string{
+	"SearchRequest.origin":          "IATA airport code",
+	"SearchRequest.destination":     "IATA airport code",
+	"SearchRequest.departureDate":   "YYYY-MM-DD, YYYY/MM/DD, DD-MM-YYYY or DD/MM/YYYY",
+	"SearchRequest.returnDate":      "Same formats as departureDate; searches the return leg",
+	"SearchRequest.passengers":      "1 to 9",
+	"SearchRequest.cabinClass":      "economy, premium, business or first",
+	"SearchRequest.sortBy":          "price (default), duration, departure, arrival or stops",
+	"SearchRequest.sortOrder":       "asc (default) or desc",
+	"FilterOptions.maxDuration":     "Minutes",
+	"TimeRange.start":               "Hour, 0-23",
+	"TimeRange.end":                 "Hour, 0-23",
+	"SearchMetadata.provider_cache": "Provider name -> hit, stale or miss",
+}
+
+// schemaGenerator generates JSON schemas from Go types using their json tags
+// Structs become components, referenced with $ref.
+type schemaGenerator struct {
+	components jsonObject
+	typeEnums  map[reflect.Type][]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	amenities := make([]string, len(models.AllAmenities))
+	for i, amenity := range models.AllAmenities {
+		amenities[i] = string(amenity)
+	}
+
+	return &schemaGenerator{
+		components: jsonObject{},
+		typeEnums: map[reflect.Type][]string{
+			reflect.TypeOf(models.Amenity("")): amenities,
+			reflect.TypeOf(models.BodyType("")): {
+				string(models.BodyTypeNarrow),
+				string(models.BodyTypeWide),
+				string(models.BodyTypeTurboprop),
+			},
+		},
+	}
+}
+
+// schema returns the schema of v's type
+func (g *schemaGenerator) schema(v interface{}) jsonObject {
+	return g.schemaFor(reflect.TypeOf(v))
+}
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) jsonObject {
+	if t == reflect.TypeOf(time.Time{}) {
+		return jsonObject{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaFor(t.Elem())
+	case reflect.Struct:
+		return jsonObject{"$ref": "#/components/schemas/" + g.component(t)}
+	case reflect.Slice, reflect.Array:
+		return jsonObject{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return jsonObject{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.String:
+		schema := jsonObject{"type": "string"}
+		if enum, ok := g.typeEnums[t]; ok {
+			schema["enum"] = enum
+		}
+		return schema
+	case reflect.Bool:
+		return jsonObject{"type": "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return jsonObject{"type": "integer", "format": "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return jsonObject{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return jsonObject{"type": "number"}
+	default:
+		return jsonObject{}
+	}
+}
+
+// component adds the schema of a struct type to the components and returns its name
+func (g *schemaGenerator) component(t reflect.Type) string {
+	name := t.Name()
+	if renamed, ok := openAPIComponentNames[name]; ok {
+		name = renamed
+	}
+
+	if _, ok := g.components[name]; !ok {
+		g.components[name] = jsonObject{} // placeholder, in case the type refers to itself
+		g.components[name] = g.structSchema(t)
+	}
+	return name
+}
+
+// structSchema describes the JSON encoding of a struct
+// Fields without omitempty are always encoded, so they are required; nil pointers,
+// slices and maps in such fields are encoded as null.
+func (g *schemaGenerator) structSchema(t reflect.Type) jsonObject {
+	properties := jsonObject{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		key := t.Name() + "." + name
+		schema := g.schemaFor(field.Type)
+		if enum, ok := openAPIFieldEnums[key]; ok {
+			schema["enum"] = enum
+		}
+		if description, ok := openAPIFieldDescriptions[key]; ok {
+			schema["description"] = description
+		}
+
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+			switch field.Type.Kind() {
+			case reflect.Pointer, reflect.Slice, reflect.Map:
+				schema = nullable(schema)
+			}
+		}
+		properties[name] = schema
+	}
+
+	schema := jsonObject{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// nullable allows null in addition to the schema
+// OpenAPI 3.0 ignores keywords next to $ref, so references are wrapped in allOf.
+func nullable(schema jsonObject) jsonObject {
+	if _, ok := schema["$ref"]; ok {
+		return jsonObject{"allOf": []interface{}{schema}, "nullable": true}
+	}
+	schema["nullable"] = true
+	return schema
+}
+
+// Descriptions of the error statuses
+var openAPIErrorDescriptions = map[int]string{
+	http.StatusBadRequest:          "Malformed request or validation error",
+	http.StatusUnauthorized:        "Missing or invalid admin API key",
+	http.StatusForbidden:           "Admin endpoints are disabled: no admin API key is configured",
+	http.StatusNotFound:            "No provider serves the route or sells the requested airlines, or no flights were found",
+	http.StatusConflict:            "The cache warmer is already in the requested state",
+	http.StatusTooManyRequests:     "Rate limit exceeded",
+	http.StatusInternalServerError: "Internal server error",
+	http.StatusBadGateway:          "Every queried provider failed",
+	http.StatusGatewayTimeout:      "The search or every provider timed out",
+}
+
+// buildOpenAPISpec describes every route of SetupRoutes
+func buildOpenAPISpec() jsonObject {
+	g := newSchemaGenerator()
+	errorResponse := g.schema(models.ErrorResponse{})
+	searchResponse := g.schema(models.SearchResponse{})
+	providerUpdate := g.schema(models.ProviderUpdate{})
+	warmerStatus := g.schema(service.WarmerStatus{})
+
+	// Referenced from descriptions of WebSocket messages only
+	g.schema(wsClientMessage{})
+	g.schema(wsServerMessage{})
+
+	jsonContent := func(schema jsonObject) jsonObject {
+		return jsonObject{"application/json": jsonObject{"schema": schema}}
+	}
+	ok := func(description string, schema jsonObject) jsonObject {
+		return jsonObject{"description": description, "content": jsonContent(schema)}
+	}
+	// responses adds the error statuses to the given responses
+	// Every route may be rate limited or fail unexpectedly.
+	responses := func(success jsonObject, statuses ...int) jsonObject {
+		all := jsonObject{}
+		for status, response := range success {
+			all[status] = response
+		}
+		for _, status := range append(statuses, http.StatusTooManyRequests, http.StatusInternalServerError) {
+			all[fmt.Sprint(status)] = ok(openAPIErrorDescriptions[status], errorResponse)
+		}
+		return all
+	}
+	admin := []interface{}{jsonObject{"adminKey": []string{}}}
+
+	searchBody := jsonObject{
+		"required": true,
+		"content":  jsonContent(g.schema(models.SearchRequest{})),
+	}
+	searchErrors := []int{
+		http.StatusBadRequest,
+		http.StatusNotFound,
+		http.StatusBadGateway,
+		http.StatusGatewayTimeout,
+	}
+	searchOK := jsonObject{"200": jsonObject{
+		"description": "Merged, filtered and sorted flights",
+		"headers": jsonObject{
+			"Age": jsonObject{
+				"description": "Seconds since the oldest provider results were fetched",
+				"schema":      jsonObject{"type": "integer"},
+			},
+			"Cache-Control": jsonObject{
+				"description": "public, max-age=N while every provider's results are cached and fresh, otherwise no-cache",
+				"schema":      jsonObject{"type": "string"},
+			},
+		},
+		"content": jsonContent(searchResponse),
+	}}
+
+	stream := func(summary string) jsonObject {
+		return jsonObject{
+			"tags":        []string{"search"},
+			"summary":     summary,
+			"description": "Sends a provider event (ProviderUpdate) as each provider answers, then a result event (SearchResponse), or an error event (ErrorResponse) if the search fails.",
+			"responses": responses(jsonObject{"200": jsonObject{
+				"description": "Server-Sent Events",
+				"content":     jsonObject{"text/event-stream": jsonObject{"schema": jsonObject{"type": "string"}}},
+				"x-events": jsonObject{
+					"provider": providerUpdate,
+					"result":   searchResponse,
+					"error":    errorResponse,
+				},
+			}}, http.StatusBadRequest),
+		}
+	}
+
+	warmerChange := func(summary string) jsonObject {
+		return jsonObject{
+			"tags":     []string{"admin"},
+			"summary":  summary,
+			"security": admin,
+			"responses": responses(
+				jsonObject{"200": ok("Cache warmer status", warmerStatus)},
+				http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict,
+			),
+		}
+	}
+
+	paths := jsonObject{
+		"/search": jsonObject{
+			"get": jsonObject{
+				"tags":        []string{"search"},
+				"summary":     "Search flights with query parameters",
+				"description": "Same search as POST, for bookmarkable and cacheable URLs. Return-leg filters take the filter names prefixed with return, e.g. returnMaxStops.",
+				"parameters":  searchQueryParameters(),
+				"responses":   responses(searchOK, searchErrors...),
+			},
+			"post": jsonObject{
+				"tags":        []string{"search"},
+				"summary":     "Search flights",
+				"requestBody": searchBody,
+				"responses":   responses(searchOK, searchErrors...),
+			},
+		},
+		"/search/stream": jsonObject{
+			"get": func() jsonObject {
+				op := stream("Stream a search with query parameters")
+				op["parameters"] = searchQueryParameters()
+				return op
+			}(),
+			"post": func() jsonObject {
+				op := stream("Stream a search")
+				op["requestBody"] = searchBody
+				return op
+			}(),
+		},
+		"/search/ws": jsonObject{
+			"get": jsonObject{
+				"tags":        []string{"search"},
+				"summary":     "Open a live search session over WebSocket",
+				"description": "The client sends SessionClientMessage messages: search to open the session, then refine to re-filter and re-sort its cached results. The server answers with SessionServerMessage messages: session, provider, result and error. The session closes after the idle timeout.",
+				"responses": jsonObject{
+					"101": jsonObject{"description": "Switched to the WebSocket protocol"},
+					"400": jsonObject{"description": "Not a WebSocket handshake"},
+					"403": jsonObject{"description": "The page's origin is not allowed (see server.websocket_origins)"},
+					"429": ok(openAPIErrorDescriptions[http.StatusTooManyRequests], errorResponse),
+				},
+			},
+		},
+		"/health": jsonObject{
+			"get": jsonObject{
+				"tags":    []string{"service"},
+				"summary": "Health check",
+				"responses": responses(jsonObject{"200": ok("Service is healthy", jsonObject{
+					"type":       "object",
+					"properties": jsonObject{"status": jsonObject{"type": "string", "enum": []string{"healthy"}}},
+					"required":   []string{"status"},
+				})}),
+			},
+		},
+		"/providers": jsonObject{
+			"get": jsonObject{
+				"tags":    []string{"service"},
+				"summary": "List providers and their status",
+				"responses": responses(jsonObject{"200": ok("Providers", jsonObject{
+					"type":       "object",
+					"properties": jsonObject{"providers": nullable(g.schema([]models.ProviderStatus{}))},
+					"required":   []string{"providers"},
+				})}),
+			},
+		},
+		"/openapi.json": jsonObject{
+			"get": jsonObject{
+				"tags":      []string{"service"},
+				"summary":   "This OpenAPI specification",
+				"responses": responses(jsonObject{"200": ok("OpenAPI 3 document", jsonObject{"type": "object"})}),
+			},
+		},
+		"/admin/cache": jsonObject{
+			"delete": jsonObject{
+				"tags":        []string{"admin"},
+				"summary":     "Invalidate cached provider results",
+				"description": "At least one selector is required, or all=true to purge every cached result.",
+				"security":    admin,
+				"parameters": []jsonObject{
+					queryParameter("route", "string", false, "ORIGIN-DESTINATION, e.g. CGK-DPS"),
+					queryParameter("origin", "string", false, "IATA airport code"),
+					queryParameter("destination", "string", false, "IATA airport code"),
+					queryParameter("date", "string", false, "Departure date, YYYY-MM-DD"),
+					queryParameter("provider", "string", false, "Provider name"),
+					queryParameter("all", "boolean", false, "Purge every cached result; cannot be combined with selectors"),
+				},
+				"responses": responses(jsonObject{"200": ok("Number of cache entries deleted", jsonObject{
+					"type":       "object",
+					"properties": jsonObject{"deleted": jsonObject{"type": "integer"}},
+					"required":   []string{"deleted"},
+				})}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden),
+			},
+		},
+		"/admin/cache/stats": jsonObject{
+			"get": jsonObject{
+				"tags":      []string{"admin"},
+				"summary":   "Cache statistics",
+				"security":  admin,
+				"responses": responses(jsonObject{"200": ok("Cache statistics", g.schema(cache.Stats{}))}, http.StatusUnauthorized, http.StatusForbidden),
+			},
+		},
+		"/admin/warmer": jsonObject{
+			"get": jsonObject{
+				"tags":      []string{"admin"},
+				"summary":   "Cache warmer status",
+				"security":  admin,
+				"responses": responses(jsonObject{"200": ok("Cache warmer status", warmerStatus)}, http.StatusUnauthorized, http.StatusForbidden),
+			},
+		},
+		"/admin/warmer/start": jsonObject{"post": warmerChange("Start the cache warmer")},
+		"/admin/warmer/stop":  jsonObject{"post": warmerChange("Stop the cache warmer")},
+	}
+
+	return jsonObject{
+		"openapi": "3.0.3",
+		"info": jsonObject{
+			"title":       "Flight Aggregator API",
+			"version":     "1.0.0",
+			"description": "Searches flights across providers and returns merged, filtered and sorted results.",
+		},
+		"servers": []jsonObject{{"url": openAPIBasePath}},
+		"paths":   paths,
+		"components": jsonObject{
+			"schemas": g.components,
+			"securitySchemes": jsonObject{
+				"adminKey": jsonObject{"type": "apiKey", "in": "header", "name": "X-Admin-Key"},
+			},
+		},
+	}
+}
+
+// queryParameter describes a query parameter
+func queryParameter(name, typ string, required bool, description string) jsonObject {
+	param := jsonObject{
+		"name":   name,
+		"in":     "query",
+		"schema": jsonObject{"type": typ},
+	}
+	if required {
+		param["required"] = true
+	}
+	if description != "" {
+		param["description"] = description
+	}
+	return param
+}
+
+// searchQueryParameters describes the query parameters of GET searches (see parseSearchQuery)
+func searchQueryParameters() []jsonObject {
+	params := []jsonObject{
+		queryParameter("origin", "string", true, "IATA airport code"),
+		queryParameter("destination", "string", true, "IATA airport code"),
+		queryParameter("departureDate", "string", false, "YYYY-MM-DD, YYYY/MM/DD, DD-MM-YYYY or DD/MM/YYYY; required unless date is given"),
+		queryParameter("date", "string", false, "Alias for departureDate"),
+		queryParameter("returnDate", "string", false, "Same formats as departureDate; searches the return leg"),
+		queryParameter("passengers", "integer", true, "1 to 9"),
+		queryParameter("cabinClass", "string", true, "economy, premium, business or first"),
+		queryParameter("sortBy", "string", false, "price (default), duration, departure, arrival or stops"),
+		queryParameter("sortOrder", "string", false, "asc (default) or desc"),
+		queryParameter("returnSortBy", "string", false, "sortBy for the return leg"),
+		queryParameter("returnSortOrder", "string", false, "sortOrder for the return leg"),
+	}
+
+	for _, prefix := range []string{"", "return"} {
+		param := func(name string) string {
+			if prefix == "" {
+				return name
+			}
+			return prefix + strings.ToUpper(name[:1]) + name[1:]
+		}
+		list := func(name, description string) jsonObject {
+			p := queryParameter(param(name), "array", false, description+"; comma-separated or repeated")
+			p["schema"] = jsonObject{"type": "array", "items": jsonObject{"type": "string"}}
+			p["explode"] = true
+			return p
+		}
+
+		params = append(params,
+			queryParameter(param("minPrice"), "number", false, ""),
+			queryParameter(param("maxPrice"), "number", false, ""),
+			queryParameter(param("maxStops"), "integer", false, ""),
+			queryParameter(param("maxDuration"), "integer", false, "Minutes"),
+			queryParameter(param("departureTime"), "string", false, "START-END hours, e.g. 6-12"),
+			queryParameter(param("arrivalTime"), "string", false, "START-END hours, e.g. 6-12"),
+			list("airlines", "Airline codes or names"),
+			list("aircraftFamilies", "Aircraft families, e.g. 737, A320"),
+			list("requiredAmenities", "wifi, meal, snack, entertainment or power"),
+			queryParameter(param("excludeTurboprops"), "boolean", false, ""),
+		)
+	}
+	return params
+}
+
+func TestOpenAPISpecUpToDate(t *testing.T) {
+	generated, err := json.MarshalIndent(buildOpenAPISpec(), "", "  ")
+	if err != nil {
+		t.Fatalf("encode OpenAPI spec: %v", err)
+	}
+	generated = append(generated, '\n')
+
+	if *update {
+		if err := os.WriteFile("openapi.json", generated, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	if !bytes.Equal(generated, openAPIJSON) {
+		t.Fatal("openapi.json does not match the API types; review the change and regenerate it with go test ./internal/api -run TestOpenAPISpecUpToDate -update")
+	}
+}
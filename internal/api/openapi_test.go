@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"flight-aggregator/internal/service"
+	"flight-aggregator/pkg/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAdminKey = "test-admin-key"
+
+// newTestConfig configures the four providers over the mock provider data. Each
+// provider answers after responseTime, or fails with failureRate, and times out after timeout.
+func newTestConfig(responseTime, timeout string, failureRate float64) *config.Config {
+	provider := func(name, file string) config.ProviderDetail {
+		return config.ProviderDetail{
+			Name:         name,
+			Enabled:      true,
+			ResponseTime: responseTime,
+			FailureRate:  failureRate,
+			DataPath:     "../../test_data/" + file,
+			Timeout:      timeout,
+		}
+	}
+
+	return &config.Config{
+		Cache: config.CacheConfig{TTL: "1m"},
+		Provider: config.ProviderConfig{
+			Timeout: timeout,
+			Providers: map[string]config.ProviderDetail{
+				"garuda":  provider("Garuda Indonesia", "garuda_indonesia_search_response.json"),
+				"lionair": provider("Lion Air", "lion_air_search_response.json"),
+				"batik":   provider("Batik Air", "batik_air_search_response.json"),
+				"airasia": provider("AirAsia", "airasia_search_response.json"),
+			},
+		},
+		Retry: config.RetryConfig{MaxAttempts: 1},
+	}
+}
+
+// newTestRouter creates the API routes over a search service configured by cfg
+func newTestRouter(t *testing.T, cfg *config.Config, adminAPIKey string) http.Handler {
+	t.Helper()
+
+	searchService := service.NewSearchServiceWithConfig(cfg)
+	t.Cleanup(func() { searchService.Warmer().Stop() })
+
+	return SetupRoutes(NewHandler(searchService, time.Minute), adminAPIKey)
+}
+
+// openAPICase is a request and the status its response must have
+type openAPICase struct {
+	name     string
+	method   string
+	route    string // path template of the route, as documented
+	target   string
+	body     string
+	adminKey string
+	status   int
+	ctx      context.Context
+}
+
+// checkOpenAPI sends each request and fails on an unexpected status or a response
+// that violates the spec
+func checkOpenAPI(t *testing.T, handler http.Handler, cases []openAPICase) {
+	t.Helper()
+
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.target, strings.NewReader(c.body))
+		if c.ctx != nil {
+			req = req.WithContext(c.ctx)
+		}
+		if c.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.adminKey != "" {
+			req.Header.Set("X-Admin-Key", c.adminKey)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != c.status {
+			t.Errorf("%s: %s %s returned %d, want %d: %s", c.name, c.method, c.target, rec.Code, c.status, rec.Body.String())
+			continue
+		}
+		for _, violation := range validateResponse(c.route, c.method, rec.Code, rec.Header().Get("Content-Type"), rec.Body.Bytes()) {
+			t.Errorf("%s: %s %s %d: %s", c.name, c.method, c.route, rec.Code, violation)
+		}
+	}
+}
+
+func TestCheckOpenAPISpec(t *testing.T) {
+	if err := CheckOpenAPISpec(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResponsesMatchOpenAPISpec(t *testing.T) {
+	router := newTestRouter(t, newTestConfig("20ms", "5s", 0), testAdminKey)
+
+	const search = `{"origin":"CGK","destination":"DPS","departureDate":"2025-12-15","passengers":1,"cabinClass":"economy"}`
+	const searchQuery = "origin=CGK&destination=DPS&departureDate=2025-12-15&passengers=1&cabinClass=economy"
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	checkOpenAPI(t, router, []openAPICase{
+		{name: "search", method: "POST", route: "/api/v1/search", target: "/api/v1/search", body: search, status: http.StatusOK},
+		{name: "cached search", method: "GET", route: "/api/v1/search", target: "/api/v1/search?" + searchQuery + "&maxStops=0&sortBy=duration", status: http.StatusOK},
+		{name: "round trip", method: "GET", route: "/api/v1/search", target: "/api/v1/search?" + searchQuery + "&returnDate=2025-12-20", status: http.StatusOK},
+		{name: "malformed JSON", method: "POST", route: "/api/v1/search", target: "/api/v1/search", body: `{"origin":`, status: http.StatusBadRequest},
+		{name: "invalid query", method: "GET", route: "/api/v1/search", target: "/api/v1/search?origin=CG&passengers=two", status: http.StatusBadRequest},
+		{name: "route not served", method: "GET", route: "/api/v1/search", target: "/api/v1/search?origin=JFK&destination=LHR&departureDate=2025-12-15&passengers=1&cabinClass=economy", status: http.StatusNotFound},
+		{name: "cancelled search", method: "POST", route: "/api/v1/search", target: "/api/v1/search", body: strings.Replace(search, "2025-12-15", "2025-12-16", 1), ctx: cancelled, status: http.StatusInternalServerError},
+
+		{name: "stream", method: "GET", route: "/api/v1/search/stream", target: "/api/v1/search/stream?" + searchQuery, status: http.StatusOK},
+		{name: "stream body", method: "POST", route: "/api/v1/search/stream", target: "/api/v1/search/stream", body: search, status: http.StatusOK},
+		{name: "stream error event", method: "GET", route: "/api/v1/search/stream", target: "/api/v1/search/stream?origin=JFK&destination=LHR&departureDate=2025-12-15&passengers=1&cabinClass=economy", status: http.StatusOK},
+		{name: "stream validation error event", method: "GET", route: "/api/v1/search/stream", target: "/api/v1/search/stream?origin=CGK", status: http.StatusOK},
+		{name: "malformed stream query", method: "GET", route: "/api/v1/search/stream", target: "/api/v1/search/stream?origin=CGK&passengers=two", status: http.StatusBadRequest},
+
+		{name: "health", method: "GET", route: "/api/v1/health", target: "/api/v1/health", status: http.StatusOK},
+		{name: "providers", method: "GET", route: "/api/v1/providers", target: "/api/v1/providers", status: http.StatusOK},
+		{name: "spec", method: "GET", route: "/api/v1/openapi.json", target: "/api/v1/openapi.json", status: http.StatusOK},
+
+		{name: "cache stats", method: "GET", route: "/api/v1/admin/cache/stats", target: "/api/v1/admin/cache/stats", adminKey: testAdminKey, status: http.StatusOK},
+		{name: "missing admin key", method: "GET", route: "/api/v1/admin/cache/stats", target: "/api/v1/admin/cache/stats", status: http.StatusUnauthorized},
+		{name: "wrong admin key", method: "GET", route: "/api/v1/admin/cache/stats", target: "/api/v1/admin/cache/stats", adminKey: "wrong", status: http.StatusUnauthorized},
+		{name: "invalidate", method: "DELETE", route: "/api/v1/admin/cache", target: "/api/v1/admin/cache?route=CGK-DPS", adminKey: testAdminKey, status: http.StatusOK},
+		{name: "invalidate without selector", method: "DELETE", route: "/api/v1/admin/cache", target: "/api/v1/admin/cache", adminKey: testAdminKey, status: http.StatusBadRequest},
+		{name: "warmer status", method: "GET", route: "/api/v1/admin/warmer", target: "/api/v1/admin/warmer", adminKey: testAdminKey, status: http.StatusOK},
+		{name: "start warmer", method: "POST", route: "/api/v1/admin/warmer/start", target: "/api/v1/admin/warmer/start", adminKey: testAdminKey, status: http.StatusOK},
+		{name: "start running warmer", method: "POST", route: "/api/v1/admin/warmer/start", target: "/api/v1/admin/warmer/start", adminKey: testAdminKey, status: http.StatusConflict},
+		{name: "stop warmer", method: "POST", route: "/api/v1/admin/warmer/stop", target: "/api/v1/admin/warmer/stop", adminKey: testAdminKey, status: http.StatusOK},
+		{name: "stop stopped warmer", method: "POST", route: "/api/v1/admin/warmer/stop", target: "/api/v1/admin/warmer/stop", adminKey: testAdminKey, status: http.StatusConflict},
+	})
+}
+
+func TestAdminDisabledMatchesOpenAPISpec(t *testing.T) {
+	router := newTestRouter(t, newTestConfig("20ms", "5s", 0), "")
+
+	checkOpenAPI(t, router, []openAPICase{
+		{name: "admin disabled", method: "GET", route: "/api/v1/admin/warmer", target: "/api/v1/admin/warmer", adminKey: testAdminKey, status: http.StatusForbidden},
+	})
+}
+
+func TestProviderFailuresMatchOpenAPISpec(t *testing.T) {
+	const search = "origin=CGK&destination=DPS&departureDate=2025-12-15&passengers=1&cabinClass=economy"
+
+	failing := newTestRouter(t, newTestConfig("20ms", "5s", 1), testAdminKey)
+	checkOpenAPI(t, failing, []openAPICase{
+		{name: "all providers failed", method: "GET", route: "/api/v1/search", target: "/api/v1/search?" + search, status: http.StatusBadGateway},
+		{name: "providers with open circuits", method: "GET", route: "/api/v1/providers", target: "/api/v1/providers", status: http.StatusOK},
+	})
+
+	slow := newTestRouter(t, newTestConfig("1s", "50ms", 0), testAdminKey)
+	checkOpenAPI(t, slow, []openAPICase{
+		{name: "all providers timed out", method: "GET", route: "/api/v1/search", target: "/api/v1/search?" + search, status: http.StatusGatewayTimeout},
+	})
+}
+
+func TestRateLimitedResponseMatchesOpenAPISpec(t *testing.T) {
+	router := newTestRouter(t, newTestConfig("20ms", "5s", 0), testAdminKey)
+	limited := NewRateLimiter(0.001, 1).RateLimitMiddleware(router)
+
+	checkOpenAPI(t, limited, []openAPICase{
+		{name: "within limit", method: "GET", route: "/api/v1/health", target: "/api/v1/health", status: http.StatusOK},
+		{name: "rate limited", method: "GET", route: "/api/v1/health", target: "/api/v1/health", status: http.StatusTooManyRequests},
+	})
+}
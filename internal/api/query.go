@@ -0,0 +1,303 @@
+package api
+
+import (
+	"encoding/json"
+	"flight-aggregator/internal/cache"
+	"flight-aggregator/internal/models"
+	"flight-aggregator/internal/validator"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// decodeSearchRequest reads a search request from the JSON body (POST)
+// or from query parameters (GET)
+// Malformed query parameters are reported together with the validation errors of the
+// rest of the request, so clients see every problem in one response.
+func decodeSearchRequest(r *http.Request) (models.SearchRequest, error) {
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req, err := parseSearchQuery(query)
+		if err != nil {
+			err = mergeQueryErrors(err, validator.NewValidator().ValidateSearchRequest(req), query)
+		}
+		return req, err
+	}
+
+	var req models.SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// parseSearchQuery maps query parameters to a search request
+// Parameter names match the JSON field names of models.SearchRequest, with date as an
+// alias for departureDate. Filters are top-level parameters named after the fields of
+// models.FilterOptions (e.g. maxStops=0&airlines=GA,ID); return filters use the same
+// names prefixed with "return" (e.g. returnMaxStops=0). Time ranges are written as
+// START-END hours (departureTime=6-12) and lists as comma-separated or repeated values.
+// Every malformed parameter is reported, as validator.ValidationErrors naming the parameter.
+func parseSearchQuery(query url.Values) (models.SearchRequest, error) {
+	var errs validator.ValidationErrors
+
+	req := models.SearchRequest{
+		Origin:          query.Get("origin"),
+		Destination:     query.Get("destination"),
+		DepartureDate:   query.Get("departureDate"),
+		CabinClass:      query.Get("cabinClass"),
+		SortBy:          query.Get("sortBy"),
+		SortOrder:       query.Get("sortOrder"),
+		ReturnSortBy:    query.Get("returnSortBy"),
+		ReturnSortOrder: query.Get("returnSortOrder"),
+	}
+
+	if date := query.Get("date"); date != "" {
+		if req.DepartureDate != "" && req.DepartureDate != date {
+			errs = append(errs, validator.ValidationError{Field: "date", Code: validator.CodeInvalidValue, Message: "conflicts with departureDate"})
+		}
+		req.DepartureDate = date
+	}
+
+	if returnDate := query.Get("returnDate"); returnDate != "" {
+		req.ReturnDate = &returnDate
+	}
+
+	if passengers := query.Get("passengers"); passengers != "" {
+		n, err := strconv.Atoi(passengers)
+		if err != nil {
+			errs = append(errs, validator.ValidationError{Field: "passengers", Code: validator.CodeInvalidFormat, Message: "must be an integer"})
+		}
+		req.Passengers = n
+	}
+
+	req.Filters = parseFilterQuery(query, "", &errs)
+	req.ReturnFilters = parseFilterQuery(query, "return", &errs)
+
+	if len(errs) > 0 {
+		return req, errs
+	}
+	return req, nil
+}
+
+// parseFilterQuery maps the filter query parameters with the given prefix to filter options,
+// appending malformed parameters to errs. Returns nil if none of the filter parameters are present.
+func parseFilterQuery(query url.Values, prefix string, errs *validator.ValidationErrors) *models.FilterOptions {
+	var filters models.FilterOptions
+	found := false
+
+	// param returns the prefixed parameter name, e.g. maxStops or returnMaxStops
+	param := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + strings.ToUpper(name[:1]) + name[1:]
+	}
+	get := func(name string) string {
+		value := strings.TrimSpace(query.Get(param(name)))
+		found = found || value != ""
+		return value
+	}
+
+	for _, name := range []string{"minPrice", "maxPrice"} {
+		value := get(name)
+		if value == "" {
+			continue
+		}
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			*errs = append(*errs, validator.ValidationError{Field: param(name), Code: validator.CodeInvalidFormat, Message: "must be a number"})
+			continue
+		}
+		if name == "minPrice" {
+			filters.MinPrice = &price
+		} else {
+			filters.MaxPrice = &price
+		}
+	}
+
+	for _, name := range []string{"maxStops", "maxDuration"} {
+		value := get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			*errs = append(*errs, validator.ValidationError{Field: param(name), Code: validator.CodeInvalidFormat, Message: "must be an integer"})
+			continue
+		}
+		if name == "maxStops" {
+			filters.MaxStops = &n
+		} else {
+			filters.MaxDuration = &n
+		}
+	}
+
+	for _, name := range []string{"departureTime", "arrivalTime"} {
+		value := get(name)
+		if value == "" {
+			continue
+		}
+		timeRange, err := parseHourRange(value)
+		if err != nil {
+			*errs = append(*errs, validator.ValidationError{Field: param(name), Code: validator.CodeInvalidFormat, Message: "must be START-END hours, e.g. 6-12"})
+			continue
+		}
+		if name == "departureTime" {
+			filters.DepartureTime = timeRange
+		} else {
+			filters.ArrivalTime = timeRange
+		}
+	}
+
+	filters.Airlines = queryList(query, param("airlines"))
+	filters.AircraftFamilies = queryList(query, param("aircraftFamilies"))
+	for _, amenity := range queryList(query, param("requiredAmenities")) {
+		filters.RequiredAmenities = append(filters.RequiredAmenities, models.Amenity(amenity))
+	}
+	found = found || len(filters.Airlines) > 0 || len(filters.AircraftFamilies) > 0 || len(filters.RequiredAmenities) > 0
+
+	if value := get("excludeTurboprops"); value != "" {
+		exclude, err := strconv.ParseBool(value)
+		if err != nil {
+			*errs = append(*errs, validator.ValidationError{Field: param("excludeTurboprops"), Code: validator.CodeInvalidFormat, Message: "must be true or false"})
+		}
+		filters.ExcludeTurboprops = exclude
+	}
+
+	if !found {
+		return nil
+	}
+	return &filters
+}
+
+// parseHourRange parses a START-END hour range such as 6-12
+func parseHourRange(value string) (*models.TimeRange, error) {
+	start, end, ok := strings.Cut(value, "-")
+	if !ok {
+		return nil, fmt.Errorf("missing '-'")
+	}
+
+	startHour, err := strconv.Atoi(strings.TrimSpace(start))
+	if err != nil {
+		return nil, err
+	}
+	endHour, err := strconv.Atoi(strings.TrimSpace(end))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TimeRange{Start: startHour, End: endHour}, nil
+}
+
+// queryList returns a list parameter given as comma-separated and/or repeated values
+func queryList(query url.Values, name string) []string {
+	var list []string
+	for _, value := range query[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// queryParamError renames the fields of validation errors to the query parameters they
+// came from, e.g. returnFilters.departureTime.start becomes returnDepartureTime
+func queryParamError(err error, query url.Values) error {
+	validationErrs, ok := validator.AsValidationErrors(err)
+	if !ok {
+		return err
+	}
+
+	renamed := make(validator.ValidationErrors, len(validationErrs))
+	for i, validationErr := range validationErrs {
+		param := strings.TrimSuffix(strings.TrimSuffix(validationErr.Field, ".start"), ".end")
+		if name, ok := strings.CutPrefix(param, "filters."); ok {
+			param = name
+		} else if name, ok := strings.CutPrefix(param, "returnFilters."); ok {
+			param = "return" + strings.ToUpper(name[:1]) + name[1:]
+		}
+		if param == "departureDate" && query.Get("departureDate") == "" && query.Get("date") != "" {
+			param = "date"
+		}
+
+		validationErr.Field = param
+		renamed[i] = validationErr
+	}
+	return renamed
+}
+
+// mergeQueryErrors appends the validation errors of a partially parsed request to its
+// query parse errors. Parameters that failed to parse are not reported again.
+func mergeQueryErrors(parseErr, validationErr error, query url.Values) error {
+	parseErrs, ok := validator.AsValidationErrors(parseErr)
+	if !ok {
+		return parseErr
+	}
+	validationErrs, _ := validator.AsValidationErrors(queryParamError(validationErr, query))
+
+	reported := make(map[string]bool, len(parseErrs))
+	for _, parseErr := range parseErrs {
+		reported[parseErr.Field] = true
+	}
+
+	merged := append(validator.ValidationErrors(nil), parseErrs...)
+	for _, validationErr := range validationErrs {
+		if !reported[validationErr.Field] {
+			merged = append(merged, validationErr)
+		}
+	}
+	return merged
+}
+
+// parseCacheSelector maps cache invalidation query parameters to a selector
+// At least one of route, origin, destination, date or provider is required, or all=true
+// to purge every cached result.
+func parseCacheSelector(query url.Values) (cache.Selector, error) {
+	sel := cache.Selector{
+		Provider:    strings.TrimSpace(query.Get("provider")),
+		Origin:      strings.ToUpper(strings.TrimSpace(query.Get("origin"))),
+		Destination: strings.ToUpper(strings.TrimSpace(query.Get("destination"))),
+		Date:        strings.TrimSpace(query.Get("date")),
+	}
+
+	if route := query.Get("route"); route != "" {
+		origin, destination, ok := strings.Cut(strings.ToUpper(strings.TrimSpace(route)), "-")
+		if !ok || origin == "" || destination == "" {
+			return sel, validator.ValidationError{Field: "route", Code: validator.CodeInvalidFormat, Message: "must be ORIGIN-DESTINATION, e.g. CGK-DPS"}
+		}
+		if (sel.Origin != "" && sel.Origin != origin) || (sel.Destination != "" && sel.Destination != destination) {
+			return sel, validator.ValidationError{Field: "route", Code: validator.CodeInvalidValue, Message: "conflicts with origin/destination"}
+		}
+		sel.Origin, sel.Destination = origin, destination
+	}
+
+	if sel.Date != "" {
+		if _, err := time.Parse("2006-01-02", sel.Date); err != nil {
+			return sel, validator.ValidationError{Field: "date", Code: validator.CodeInvalidFormat, Message: "must be YYYY-MM-DD"}
+		}
+	}
+
+	all := false
+	if v := query.Get("all"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return sel, validator.ValidationError{Field: "all", Code: validator.CodeInvalidFormat, Message: "must be true or false"}
+		}
+		all = b
+	}
+
+	if sel == (cache.Selector{}) && !all {
+		return sel, fmt.Errorf("a selector is required: route, origin, destination, date, provider, or all=true")
+	}
+	if sel != (cache.Selector{}) && all {
+		return sel, validator.ValidationError{Field: "all", Code: validator.CodeInvalidValue, Message: "cannot be combined with other selectors"}
+	}
+
+	return sel, nil
+}
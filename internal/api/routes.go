@@ -5,14 +5,21 @@ import (
 )
 
 // SetupRoutes configures all API routes
-func SetupRoutes(h *Handler) *mux.Router {
+// Admin routes require adminAPIKey (see AdminAuthMiddleware)
+func SetupRoutes(h *Handler, adminAPIKey string) *mux.Router {
 	router := mux.NewRouter()
 
 	// API v1 routes
 	api := router.PathPrefix("/api/v1").Subrouter()
 
 	// Search endpoint
-	api.HandleFunc("/search", h.Search).Methods("POST")
+	api.HandleFunc("/search", h.Search).Methods("GET", "POST")
+
+	// Streaming search endpoint (Server-Sent Events)
+	api.HandleFunc("/search/stream", h.SearchStream).Methods("GET", "POST")
+
+	// Live search session endpoint (WebSocket)
+	api.HandleFunc("/search/ws", h.SearchSession).Methods("GET")
 
 	// Health check endpoint
 	api.HandleFunc("/health", h.Health).Methods("GET")
@@ -20,5 +27,17 @@ func SetupRoutes(h *Handler) *mux.Router {
 	// Provider status endpoint
 	api.HandleFunc("/providers", h.ListProviders).Methods("GET")
 
+	// OpenAPI specification of these routes (see openapi.json)
+	api.HandleFunc("/openapi.json", h.OpenAPI).Methods("GET")
+
+	// Admin endpoints
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(AdminAuthMiddleware(adminAPIKey))
+	admin.HandleFunc("/cache", h.InvalidateCache).Methods("DELETE")
+	admin.HandleFunc("/cache/stats", h.CacheStats).Methods("GET")
+	admin.HandleFunc("/warmer", h.WarmerStatus).Methods("GET")
+	admin.HandleFunc("/warmer/start", h.StartWarmer).Methods("POST")
+	admin.HandleFunc("/warmer/stop", h.StopWarmer).Methods("POST")
+
 	return router
 }
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"errors"
+	"flight-aggregator/internal/models"
+	"flight-aggregator/internal/service"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket message types
+const (
+	wsTypeSearch   = "search"   // client: open a session with a search request
+	wsTypeRefine   = "refine"   // client: apply new filters and sort options
+	wsTypeSession  = "session"  // server: session opened
+	wsTypeProvider = "provider" // server: a provider's results arrived
+	wsTypeResult   = "result"   // server: filtered and sorted response
+	wsTypeError    = "error"    // server: request failed
+)
+
+// defaultSessionIdleTimeout is used when no idle timeout is configured
+const defaultSessionIdleTimeout = 5 * time.Minute
+
+// SetWebSocketOrigins allows live search sessions from pages on other origins,
+// e.g. "https://app.example.com". Only same-origin pages are allowed by default.
+func (h *Handler) SetWebSocketOrigins(origins []string) {
+	h.webSocketOrigins = make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		h.webSocketOrigins[normalizeOrigin(origin)] = true
+	}
+}
+
+// checkOrigin allows WebSocket handshakes from the server's own origin, from the
+// configured origins, and from non-browser clients, which send no Origin header.
+// Other sites cannot open sessions with their visitors' browsers.
+func (h *Handler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return h.webSocketOrigins[normalizeOrigin(origin)]
+}
+
+// normalizeOrigin formats an origin for comparison
+func normalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+}
+
+// wsClientMessage represents a message sent by the client
+type wsClientMessage struct {
+	Type       string                    `json:"type"`
+	Request    *models.SearchRequest     `json:"request,omitempty"`    // for "search"
+	Refinement *models.SessionRefinement `json:"refinement,omitempty"` // for "refine"
+}
+
+// wsServerMessage represents a message sent to the client
+type wsServerMessage struct {
+	Type      string                 `json:"type"`
+	SessionID string                 `json:"sessionId,omitempty"`
+	Update    *models.ProviderUpdate `json:"update,omitempty"`
+	Response  *models.SearchResponse `json:"response,omitempty"`
+	Error     *models.ErrorResponse  `json:"error,omitempty"`
+}
+
+// SearchSession handles live search sessions over WebSocket.
+// The client sends a "search" message to open a session and receives a
+// "session" message, a "provider" message per provider as results arrive,
+// and a "result" message. It may then send "refine" messages, each answered
+// with a "result" computed from the session's cached results without
+// re-querying providers. The session closes after the idle timeout or when a
+// message cannot be sent.
+func (h *Handler) SearchSession(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     h.checkOrigin,
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	var sessionID string
+	defer func() {
+		if sessionID != "" {
+			h.searchService.CloseSession(sessionID)
+		}
+	}()
+
+	// send writes a message to the client; the session ends when a write fails
+	send := func(msg wsServerMessage) bool {
+		if err := conn.WriteJSON(msg); err != nil {
+			log.Printf("Search session %s write failed: %v", sessionID, err)
+			return false
+		}
+		return true
+	}
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(h.sessionIdleTimeout))
+
+		var msg wsClientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			var netErr interface{ Timeout() bool }
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				log.Printf("Search session %s idle for %v, closing", sessionID, h.sessionIdleTimeout)
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session idle timeout"),
+					time.Now().Add(time.Second))
+			} else if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("Search session %s read failed: %v", sessionID, err)
+			}
+			return
+		}
+
+		switch msg.Type {
+		case wsTypeSearch:
+			if msg.Request == nil {
+				if !send(wsErrorMessage(newErrorResponse(http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid message", "search message requires a request"))) {
+					return
+				}
+				continue
+			}
+
+			// A new search replaces the current session
+			if sessionID != "" {
+				h.searchService.CloseSession(sessionID)
+				sessionID = ""
+			}
+
+			writeFailed := false
+			id, response, err := h.searchService.OpenSession(r.Context(), *msg.Request, func(update models.ProviderUpdate) {
+				if !writeFailed {
+					writeFailed = !send(wsServerMessage{Type: wsTypeProvider, Update: &update})
+				}
+			})
+			if err != nil {
+				if writeFailed || !send(wsErrorMessage(classifyError(err))) {
+					return
+				}
+				continue
+			}
+
+			sessionID = id
+			if writeFailed ||
+				!send(wsServerMessage{Type: wsTypeSession, SessionID: sessionID}) ||
+				!send(wsServerMessage{Type: wsTypeResult, SessionID: sessionID, Response: response}) {
+				return
+			}
+
+		case wsTypeRefine:
+			if sessionID == "" {
+				if !send(wsErrorMessage(newErrorResponse(http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid message", "no active search session; send a search message first"))) {
+					return
+				}
+				continue
+			}
+
+			refinement := models.SessionRefinement{}
+			if msg.Refinement != nil {
+				refinement = *msg.Refinement
+			}
+
+			response, err := h.searchService.RefineSession(sessionID, refinement)
+			if err != nil {
+				if errors.Is(err, service.ErrSessionNotFound) {
+					sessionID = ""
+				}
+				if !send(wsErrorMessage(classifyError(err))) {
+					return
+				}
+				continue
+			}
+
+			if !send(wsServerMessage{Type: wsTypeResult, SessionID: sessionID, Response: response}) {
+				return
+			}
+
+		default:
+			if !send(wsErrorMessage(newErrorResponse(http.StatusBadRequest, models.ErrorCodeInvalidRequest, "Invalid message", "unknown message type: "+msg.Type))) {
+				return
+			}
+		}
+	}
+}
+
+// wsErrorMessage wraps an error response in a WebSocket message
+func wsErrorMessage(response models.ErrorResponse) wsServerMessage {
+	return wsServerMessage{Type: wsTypeError, Error: &response}
+}
@@ -1,138 +1,203 @@
 package cache
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 	"flight-aggregator/internal/models"
 	"fmt"
-	"sync"
+	"log"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
-// CacheEntry represents a cached item with expiration
-type CacheEntry struct {
-	Data      interface{}
-	ExpiresAt time.Time
-}
-
-// IsExpired checks if the cache entry has expired
-func (e *CacheEntry) IsExpired() bool {
-	return time.Now().After(e.ExpiresAt)
-}
+// resultKeyPrefix namespaces provider results in the store
+const resultKeyPrefix = "results:"
 
-// Cache represents an in-memory cache with TTL
+// Cache stores JSON-serialized values in a Store with a default TTL
+// Values are copied in and out, so callers never share cached data.
+// Entries past their TTL remain available to GetStale for the stale window.
 type Cache struct {
-	data  map[string]*CacheEntry
-	mu    sync.RWMutex
-	ttl   time.Duration
-	stats Stats
-}
-
-// Stats tracks cache performance metrics
-type Stats struct {
-	Hits          int64
-	Misses        int64
-	Evictions     int64
-	Expirations   int64
-	CurrentSize   int
-	TotalRequests int64
-}
-
-// New creates a new cache with the specified TTL
-func New(ttl time.Duration) *Cache {
-	c := &Cache{
-		data: make(map[string]*CacheEntry),
-		ttl:  ttl,
-	}
+	store       Store
+	ttl         time.Duration
+	staleWindow time.Duration
+
+	staleHits     atomic.Int64
+	refreshes     atomic.Int64
+	refreshErrors atomic.Int64
+}
 
-	// Start background cleanup goroutine
-	go c.cleanupExpired()
+// envelope wraps a cached value with its freshness
+type envelope struct {
+	CachedAt   time.Time       `json:"cached_at"`
+	FreshUntil time.Time       `json:"fresh_until"`
+	Data       json.RawMessage `json:"data"`
+}
 
-	return c
+// Freshness describes when a cached value was stored and until when it is fresh
+type Freshness struct {
+	CachedAt   time.Time
+	FreshUntil time.Time
 }
 
-// Set stores a value in the cache with TTL
-func (c *Cache) Set(key string, value interface{}) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// Stale reports whether the value is past its TTL
+func (f Freshness) Stale() bool {
+	return time.Now().After(f.FreshUntil)
+}
 
-	c.data[key] = &CacheEntry{
-		Data:      value,
-		ExpiresAt: time.Now().Add(c.ttl),
+// New creates a new cache on top of store with the specified default TTL
+func New(store Store, ttl time.Duration) *Cache {
+	return &Cache{
+		store: store,
+		ttl:   ttl,
 	}
+}
 
-	c.stats.CurrentSize = len(c.data)
+// SetStaleWindow sets how long entries remain available to GetStale after their TTL
+// Must be called before the cache is used
+func (c *Cache) SetStaleWindow(d time.Duration) {
+	c.staleWindow = d
 }
 
-// Get retrieves a value from the cache
-// Returns (value, true) if found and not expired, (nil, false) otherwise
-func (c *Cache) Get(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+// Set stores a value in the cache with TTL
+func (c *Cache) Set(key string, value interface{}) {
+	c.SetWithTTL(key, value, c.ttl)
+}
 
-	c.stats.TotalRequests++
+// SetWithTTL stores a value in the cache with a custom TTL and returns its freshness
+// A ttl of zero or less uses the cache's default TTL
+func (c *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration) (Freshness, bool) {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
 
-	entry, exists := c.data[key]
-	if !exists {
-		c.stats.Misses++
-		return nil, false
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to serialize cache value for key %s: %v", key, err)
+		return Freshness{}, false
 	}
 
-	if entry.IsExpired() {
-		c.stats.Misses++
-		c.stats.Expirations++
-		// Don't delete here to avoid deadlock, will be cleaned up by background goroutine
-		return nil, false
+	now := time.Now()
+	data, err = json.Marshal(envelope{CachedAt: now, FreshUntil: now.Add(ttl), Data: data})
+	if err != nil {
+		log.Printf("Failed to serialize cache entry for key %s: %v", key, err)
+		return Freshness{}, false
 	}
 
-	c.stats.Hits++
-	return entry.Data, true
+	c.store.Set(key, data, ttl+c.staleWindow)
+	return Freshness{CachedAt: now, FreshUntil: now.Add(ttl)}, true
 }
 
-// cleanupExpired removes expired entries periodically
-func (c *Cache) cleanupExpired() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+// Delete removes a value from the cache
+func (c *Cache) Delete(key string) {
+	c.store.Delete(key)
+}
 
-	for range ticker.C {
-		c.removeExpiredEntries()
-	}
+// Get decodes the cached value for key into value
+// Returns true if found and not expired, false otherwise
+func (c *Cache) Get(key string, value interface{}) bool {
+	freshness, found := c.GetStale(key, value)
+	return found && !freshness.Stale()
 }
 
-// removeExpiredEntries scans and removes expired entries
-func (c *Cache) removeExpiredEntries() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// GetStale decodes the cached value for key into value, including entries past their
+// TTL but within the stale window, and returns the value's freshness. Callers serving
+// stale values should refresh them and report the outcome with RecordRefresh.
+func (c *Cache) GetStale(key string, value interface{}) (Freshness, bool) {
+	data, ok := c.store.Get(key)
+	if !ok {
+		return Freshness{}, false
+	}
 
-	now := time.Now()
-	expiredCount := 0
+	var entry envelope
+	if err := json.Unmarshal(data, &entry); err != nil {
+		log.Printf("Failed to decode cache entry for key %s: %v", key, err)
+		return Freshness{}, false
+	}
+	if err := json.Unmarshal(entry.Data, value); err != nil {
+		log.Printf("Failed to decode cache value for key %s: %v", key, err)
+		return Freshness{}, false
+	}
 
-	for key, entry := range c.data {
-		if now.After(entry.ExpiresAt) {
-			delete(c.data, key)
-			expiredCount++
-		}
+	freshness := Freshness{CachedAt: entry.CachedAt, FreshUntil: entry.FreshUntil}
+	if freshness.Stale() {
+		c.staleHits.Add(1)
+	}
+	return freshness, true
+}
+
+// Peek returns the freshness of the cached value for key, including entries within the
+// stale window, without decoding the value or recording hits, misses or stale hits
+func (c *Cache) Peek(key string) (Freshness, bool) {
+	data, ok := c.store.Peek(key)
+	if !ok {
+		return Freshness{}, false
 	}
 
-	if expiredCount > 0 {
-		c.stats.Expirations += int64(expiredCount)
-		c.stats.CurrentSize = len(c.data)
+	var entry envelope
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Freshness{}, false
 	}
+	return Freshness{CachedAt: entry.CachedAt, FreshUntil: entry.FreshUntil}, true
 }
 
-// GenerateKey creates a cache key from an object by hashing its JSON representation
-func GenerateKey(prefix string, obj interface{}) string {
-	data, err := json.Marshal(obj)
+// RecordRefresh records the outcome of a background refresh of a stale entry
+func (c *Cache) RecordRefresh(err error) {
 	if err != nil {
-		// Fallback to string representation if marshaling fails
-		return fmt.Sprintf("%s:%v", prefix, obj)
+		c.refreshErrors.Add(1)
+		return
 	}
+	c.refreshes.Add(1)
+}
+
+// Stats returns a snapshot of the cache statistics
+func (c *Cache) Stats() Stats {
+	stats := c.store.Stats()
+	stats.StaleHits = c.staleHits.Load()
+	stats.Refreshes = c.refreshes.Load()
+	stats.RefreshErrors = c.refreshErrors.Load()
+	return stats
+}
+
+// Selector selects cached provider results to invalidate
+// Set fields must all match; an empty selector matches every provider result.
+type Selector struct {
+	Provider    string // provider name, case-insensitive
+	Origin      string
+	Destination string
+	Date        string // departure date, YYYY-MM-DD
+}
+
+// Invalidate removes the cached provider results matching the selector
+// Returns the number of entries removed. Live search sessions are not affected.
+func (c *Cache) Invalidate(sel Selector) int {
+	return c.store.DeleteMatching(func(key string) bool {
+		if !strings.HasPrefix(key, resultKeyPrefix) {
+			return false
+		}
+
+		// provider:origin:destination:date:passengers:cabin
+		parts := strings.Split(strings.TrimPrefix(key, resultKeyPrefix), ":")
+		if len(parts) != 6 {
+			return false
+		}
 
-	hash := sha256.Sum256(data)
-	return fmt.Sprintf("%s:%x", prefix, hash[:8]) // Use first 8 bytes of hash
+		return (sel.Provider == "" || strings.EqualFold(parts[0], sel.Provider)) &&
+			(sel.Origin == "" || strings.EqualFold(parts[1], sel.Origin)) &&
+			(sel.Destination == "" || strings.EqualFold(parts[2], sel.Destination)) &&
+			(sel.Date == "" || parts[3] == sel.Date)
+	})
 }
 
-// GenerateKey generates a cache key from a search request
-func (c *Cache) GenerateKey(req models.SearchRequest) string {
-	return GenerateKey("search", req)
+// GenerateKey generates a cache key for one provider's results for a search request
+// Only the fields that change what providers return are used: route, date, passengers
+// and cabin class. Filters and sorting are applied to the cached results on every request.
+func (c *Cache) GenerateKey(provider string, req models.SearchRequest) string {
+	return fmt.Sprintf("%s%s:%s:%s:%s:%d:%s",
+		resultKeyPrefix,
+		provider,
+		strings.ToUpper(req.Origin),
+		strings.ToUpper(req.Destination),
+		req.DepartureDate,
+		req.Passengers,
+		strings.ToLower(req.CabinClass))
 }
@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// entryOverhead approximates the per-entry memory used besides the key and value
+const entryOverhead = 128
+
+// CacheEntry represents a cached item with expiration
+type CacheEntry struct {
+	Key       string
+	Data      []byte
+	ExpiresAt time.Time
+	Size      int64 // approximate memory used by the entry in bytes
+}
+
+// IsExpired checks if the cache entry has expired
+func (e *CacheEntry) IsExpired() bool {
+	return time.Now().After(e.ExpiresAt)
+}
+
+// MemoryStore is an in-memory Store with TTL and LRU eviction
+// When maxEntries or maxBytes is exceeded, the least recently used entries are evicted
+type MemoryStore struct {
+	data       map[string]*list.Element // key -> element in lru holding a *CacheEntry
+	lru        *list.List               // front is the most recently used
+	mu         sync.Mutex
+	maxEntries int   // zero means unlimited
+	maxBytes   int64 // zero means unlimited
+	stats      Stats
+}
+
+// NewMemoryStore creates an in-memory store that holds at most maxEntries entries
+// and approximately maxBytes bytes (zero means unlimited)
+func NewMemoryStore(maxEntries int, maxBytes int64) *MemoryStore {
+	s := &MemoryStore{
+		data:       make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+
+	// Start background cleanup goroutine
+	go s.cleanupExpired()
+
+	return s
+}
+
+// Set stores a value in the store with TTL
+func (s *MemoryStore) Set(key string, value []byte, ttl time.Duration) {
+	entry := &CacheEntry{
+		Key:       key,
+		Data:      value,
+		ExpiresAt: time.Now().Add(ttl),
+		Size:      int64(len(key) + len(value) + entryOverhead),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, exists := s.data[key]; exists {
+		s.removeElement(elem)
+	}
+
+	s.data[key] = s.lru.PushFront(entry)
+	s.stats.CurrentBytes += entry.Size
+	s.evict()
+
+	s.stats.CurrentSize = len(s.data)
+}
+
+// Delete removes a value from the store
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, exists := s.data[key]; exists {
+		s.removeElement(elem)
+	}
+	s.stats.CurrentSize = len(s.data)
+}
+
+// DeleteMatching removes every entry whose key matches
+func (s *MemoryStore) DeleteMatching(match func(key string) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for key, elem := range s.data {
+		if match(key) {
+			s.removeElement(elem)
+			deleted++
+		}
+	}
+	s.stats.CurrentSize = len(s.data)
+	return deleted
+}
+
+// Get retrieves a value from the store
+// Returns (value, true) if found and not expired, (nil, false) otherwise
+func (s *MemoryStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.TotalRequests++
+
+	elem, exists := s.data[key]
+	if !exists {
+		s.stats.Misses++
+		return nil, false
+	}
+
+	entry := elem.Value.(*CacheEntry)
+	if entry.IsExpired() {
+		s.stats.Misses++
+		s.stats.Expirations++
+		s.removeElement(elem)
+		s.stats.CurrentSize = len(s.data)
+		return nil, false
+	}
+
+	s.lru.MoveToFront(elem)
+	s.stats.Hits++
+	return entry.Data, true
+}
+
+// Peek retrieves a value without recording stats or touching the LRU order
+func (s *MemoryStore) Peek(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, exists := s.data[key]
+	if !exists {
+		return nil, false
+	}
+
+	entry := elem.Value.(*CacheEntry)
+	if entry.IsExpired() {
+		return nil, false
+	}
+	return entry.Data, true
+}
+
+// Stats returns a snapshot of the store statistics
+func (s *MemoryStore) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Backend = "memory"
+	stats.MaxEntries = s.maxEntries
+	stats.MaxBytes = s.maxBytes
+	return stats
+}
+
+// evict removes least recently used entries until the store is within its limits
+// The most recently added entry is always kept. Must be called with the lock held.
+func (s *MemoryStore) evict() {
+	for s.lru.Len() > 1 &&
+		((s.maxEntries > 0 && s.lru.Len() > s.maxEntries) ||
+			(s.maxBytes > 0 && s.stats.CurrentBytes > s.maxBytes)) {
+		s.removeElement(s.lru.Back())
+		s.stats.Evictions++
+	}
+}
+
+// removeElement removes an entry from the store. Must be called with the lock held.
+func (s *MemoryStore) removeElement(elem *list.Element) {
+	entry := s.lru.Remove(elem).(*CacheEntry)
+	delete(s.data, entry.Key)
+	s.stats.CurrentBytes -= entry.Size
+}
+
+// cleanupExpired removes expired entries periodically
+func (s *MemoryStore) cleanupExpired() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.removeExpiredEntries()
+	}
+}
+
+// removeExpiredEntries scans and removes expired entries
+func (s *MemoryStore) removeExpiredEntries() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	expiredCount := 0
+
+	for _, elem := range s.data {
+		if now.After(elem.Value.(*CacheEntry).ExpiresAt) {
+			s.removeElement(elem)
+			expiredCount++
+		}
+	}
+
+	if expiredCount > 0 {
+		s.stats.Expirations += int64(expiredCount)
+		s.stats.CurrentSize = len(s.data)
+	}
+}
@@ -0,0 +1,365 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// redisPoolSize is the number of idle connections kept for reuse
+const redisPoolSize = 8
+
+// errNil is returned for Redis nil replies
+var errNil = errors.New("redis: nil")
+
+// RedisConfig holds the connection settings for a RedisStore
+type RedisConfig struct {
+	Addr      string
+	Password  string
+	DB        int
+	KeyPrefix string        // prepended to every key, so instances can share a server
+	Timeout   time.Duration // dial, read and write timeout per command
+}
+
+// RedisStore is a Store backed by any server speaking the Redis protocol (RESP)
+// Values are stored with SET ... PX so the server expires them.
+type RedisStore struct {
+	config RedisConfig
+	pool   chan *redisConn // idle connections
+
+	hits     atomic.Int64
+	misses   atomic.Int64
+	errors   atomic.Int64
+	requests atomic.Int64
+}
+
+// redisConn is a single connection to the server
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedisStore creates a Redis-protocol store and checks the server with PING
+func NewRedisStore(config RedisConfig) (*RedisStore, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = time.Second
+	}
+
+	s := &RedisStore{
+		config: config,
+		pool:   make(chan *redisConn, redisPoolSize),
+	}
+
+	if _, err := s.do("PING"); err != nil {
+		return nil, fmt.Errorf("redis %s: %w", config.Addr, err)
+	}
+
+	return s, nil
+}
+
+// Get retrieves a value from the server
+// Returns (value, true) if found, (nil, false) if missing or on error
+func (s *RedisStore) Get(key string) ([]byte, bool) {
+	s.requests.Add(1)
+
+	value, ok := s.Peek(key)
+	if !ok {
+		s.misses.Add(1)
+		return nil, false
+	}
+
+	s.hits.Add(1)
+	return value, true
+}
+
+// Peek retrieves a value from the server without counting a hit or miss
+// Backend errors are still counted.
+func (s *RedisStore) Peek(key string) ([]byte, bool) {
+	reply, err := s.do("GET", s.config.KeyPrefix+key)
+	if errors.Is(err, errNil) {
+		return nil, false
+	}
+	if err != nil {
+		s.errors.Add(1)
+		log.Printf("Redis GET %s failed: %v", key, err)
+		return nil, false
+	}
+
+	value, ok := reply.([]byte)
+	if !ok {
+		s.errors.Add(1)
+		log.Printf("Redis GET %s returned unexpected reply %T", key, reply)
+		return nil, false
+	}
+	return value, true
+}
+
+// Set stores a value on the server with TTL
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration) {
+	ttlMs := ttl.Milliseconds()
+	if ttlMs <= 0 {
+		ttlMs = 1
+	}
+
+	if _, err := s.do("SET", s.config.KeyPrefix+key, string(value), "PX", strconv.FormatInt(ttlMs, 10)); err != nil {
+		s.errors.Add(1)
+		log.Printf("Redis SET %s failed: %v", key, err)
+	}
+}
+
+// Delete removes a value from the server
+func (s *RedisStore) Delete(key string) {
+	if _, err := s.do("DEL", s.config.KeyPrefix+key); err != nil {
+		s.errors.Add(1)
+		log.Printf("Redis DEL %s failed: %v", key, err)
+	}
+}
+
+// DeleteMatching scans the keys under the key prefix and removes those that match
+func (s *RedisStore) DeleteMatching(match func(key string) bool) int {
+	deleted := 0
+	err := s.scan(func(keys []string) {
+		args := []string{"DEL"}
+		for _, key := range keys {
+			if match(strings.TrimPrefix(key, s.config.KeyPrefix)) {
+				args = append(args, key)
+			}
+		}
+		if len(args) == 1 {
+			return
+		}
+
+		reply, err := s.do(args...)
+		if err != nil {
+			s.errors.Add(1)
+			log.Printf("Redis DEL failed: %v", err)
+		} else if n, ok := reply.(int64); ok {
+			deleted += int(n)
+		}
+	})
+	if err != nil {
+		s.errors.Add(1)
+		log.Printf("Redis SCAN failed: %v", err)
+	}
+	return deleted
+}
+
+// Stats returns this instance's request counters and the number of keys under the key prefix
+// Without a key prefix the whole database is counted. Evictions and memory usage are
+// managed by the server and not reported here.
+func (s *RedisStore) Stats() Stats {
+	stats := Stats{
+		Backend:       "redis",
+		Hits:          s.hits.Load(),
+		Misses:        s.misses.Load(),
+		Errors:        s.errors.Load(),
+		TotalRequests: s.requests.Load(),
+	}
+
+	if s.config.KeyPrefix == "" {
+		if reply, err := s.do("DBSIZE"); err == nil {
+			if size, ok := reply.(int64); ok {
+				stats.CurrentSize = int(size)
+			}
+		}
+		return stats
+	}
+
+	// Other applications' keys may share the database, so only prefixed keys are counted
+	if err := s.scan(func(keys []string) { stats.CurrentSize += len(keys) }); err != nil {
+		log.Printf("Redis SCAN failed: %v", err)
+	}
+	return stats
+}
+
+// scan calls visit with each page of keys under the key prefix, using SCAN so the
+// server is not blocked. Keys are passed with their prefix.
+func (s *RedisStore) scan(visit func(keys []string)) error {
+	cursor := "0"
+	for {
+		reply, err := s.do("SCAN", cursor, "MATCH", s.config.KeyPrefix+"*", "COUNT", "100")
+		if err != nil {
+			return err
+		}
+
+		page, ok := reply.([]interface{})
+		if !ok || len(page) != 2 {
+			return fmt.Errorf("redis: unexpected SCAN reply %T", reply)
+		}
+		next, _ := page[0].([]byte)
+		items, _ := page[1].([]interface{})
+
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			if key, ok := item.([]byte); ok {
+				keys = append(keys, string(key))
+			}
+		}
+		visit(keys)
+
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// do sends a command and reads its reply, reusing pooled connections
+// Replies are []byte (bulk strings), string (simple strings), int64 or []interface{}.
+func (s *RedisStore) do(args ...string) (interface{}, error) {
+	conn, err := s.getConn()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.do(s.config.Timeout, args...)
+	var replyErr redisError
+	if err != nil && !errors.Is(err, errNil) && !errors.As(err, &replyErr) {
+		// Connection state is unknown after I/O errors
+		conn.conn.Close()
+		return nil, err
+	}
+
+	s.putConn(conn)
+	return reply, err
+}
+
+// getConn returns an idle connection or dials a new one
+func (s *RedisStore) getConn() (*redisConn, error) {
+	select {
+	case conn := <-s.pool:
+		return conn, nil
+	default:
+	}
+
+	netConn, err := net.DialTimeout("tcp", s.config.Addr, s.config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{conn: netConn, reader: bufio.NewReader(netConn)}
+
+	if s.config.Password != "" {
+		if _, err := conn.do(s.config.Timeout, "AUTH", s.config.Password); err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+	}
+	if s.config.DB != 0 {
+		if _, err := conn.do(s.config.Timeout, "SELECT", strconv.Itoa(s.config.DB)); err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("select db %d: %w", s.config.DB, err)
+		}
+	}
+
+	return conn, nil
+}
+
+// putConn returns a connection to the pool, closing it if the pool is full
+func (s *RedisStore) putConn(conn *redisConn) {
+	select {
+	case s.pool <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+// redisError is an error reply from the server
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// do writes a command as a RESP array of bulk strings and reads the reply
+func (c *redisConn) do(timeout time.Duration, args ...string) (interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(timeout))
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return c.readReply()
+}
+
+// readReply reads a single RESP reply
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, errNil
+		}
+		data := make([]byte, n+2) // payload and trailing CRLF
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line)
+		}
+		if n < 0 {
+			return nil, errNil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := c.readReply()
+			if err != nil && !errors.Is(err, errNil) {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// readLine reads a CRLF-terminated line without the terminator
+func (c *redisConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("redis: malformed line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,386 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is an in-process server speaking the subset of RESP used by RedisStore
+type fakeRedis struct {
+	addr     string
+	password string
+
+	mu      sync.Mutex
+	data    map[string]fakeRedisEntry
+	nextSeq int
+}
+
+type fakeRedisEntry struct {
+	value     string
+	expiresAt time.Time
+	// seq orders keys for SCAN so that deletes between pages don't shift the cursor
+	seq int
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeRedis{
+		addr:     listener.Addr().String(),
+		password: password,
+		data:     make(map[string]fakeRedisEntry),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	return f
+}
+
+// put stores a key directly, as another application sharing the server would
+func (f *fakeRedis) put(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[key] = f.newEntry(value, time.Time{})
+}
+
+// newEntry must be called with f.mu held
+func (f *fakeRedis) newEntry(value string, expiresAt time.Time) fakeRedisEntry {
+	f.nextSeq++
+	return fakeRedisEntry{value: value, expiresAt: expiresAt, seq: f.nextSeq}
+}
+
+// keys returns the live keys in SCAN order
+func (f *fakeRedis) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.data))
+	for key, entry := range f.data {
+		if entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return f.data[keys[i]].seq < f.data[keys[j]].seq })
+	return keys
+}
+
+func (f *fakeRedis) seqOf(key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.data[key].seq
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	authenticated := f.password == ""
+	for {
+		args, err := readFakeCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write([]byte(f.exec(args, &authenticated))); err != nil {
+			return
+		}
+	}
+}
+
+// readFakeCommand reads a command sent as a RESP array of bulk strings
+func readFakeCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("expected array, got %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func (f *fakeRedis) exec(args []string, authenticated *bool) string {
+	command := strings.ToUpper(args[0])
+	if command == "AUTH" {
+		if len(args) != 2 || args[1] != f.password {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		*authenticated = true
+		return "+OK\r\n"
+	}
+	if !*authenticated {
+		return "-NOAUTH Authentication required.\r\n"
+	}
+
+	switch command {
+	case "PING":
+		return "+PONG\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "SET":
+		var expiresAt time.Time
+		if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+			ms, _ := strconv.Atoi(args[4])
+			expiresAt = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		f.mu.Lock()
+		f.data[args[1]] = f.newEntry(args[2], expiresAt)
+		f.mu.Unlock()
+		return "+OK\r\n"
+	case "GET":
+		f.mu.Lock()
+		entry, ok := f.data[args[1]]
+		f.mu.Unlock()
+		if !ok || (!entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt)) {
+			return "$-1\r\n"
+		}
+		return bulk(entry.value)
+	case "DEL":
+		f.mu.Lock()
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := f.data[key]; ok {
+				delete(f.data, key)
+				deleted++
+			}
+		}
+		f.mu.Unlock()
+		return fmt.Sprintf(":%d\r\n", deleted)
+	case "DBSIZE":
+		return fmt.Sprintf(":%d\r\n", len(f.keys()))
+	case "SCAN":
+		// SCAN cursor MATCH pattern COUNT n, with the cursor the sequence number to resume from
+		cursor, _ := strconv.Atoi(args[1])
+		pattern, count := args[3], 10
+		if len(args) == 6 {
+			count, _ = strconv.Atoi(args[5])
+		}
+
+		var remaining []string
+		for _, key := range f.keys() {
+			if f.seqOf(key) >= cursor {
+				remaining = append(remaining, key)
+			}
+		}
+		visited := remaining[:min(count, len(remaining))]
+
+		var page []string
+		for _, key := range visited {
+			if matched, _ := path.Match(pattern, key); matched {
+				page = append(page, key)
+			}
+		}
+
+		next := "0"
+		if len(visited) < len(remaining) {
+			next = strconv.Itoa(f.seqOf(remaining[len(visited)]))
+		}
+		reply := "*2\r\n" + bulk(next) + fmt.Sprintf("*%d\r\n", len(page))
+		for _, key := range page {
+			reply += bulk(key)
+		}
+		return reply
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func newTestRedisStore(t *testing.T, server *fakeRedis, password string) *RedisStore {
+	t.Helper()
+
+	store, err := NewRedisStore(RedisConfig{
+		Addr:      server.addr,
+		Password:  password,
+		KeyPrefix: "fa:",
+		Timeout:   time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewRedisStore: %v", err)
+	}
+	return store
+}
+
+func TestRedisStoreSetGetDelete(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	store := newTestRedisStore(t, server, "secret")
+
+	// Values are binary-safe, including CRLF
+	value := []byte("{\"flights\":[]}\r\n$3\r\n*1")
+	store.Set("results:a", value, time.Minute)
+
+	got, ok := store.Get("results:a")
+	if !ok || string(got) != string(value) {
+		t.Fatalf("Get = %q, %v; want %q, true", got, ok, value)
+	}
+	if keys := server.keys(); len(keys) != 1 || keys[0] != "fa:results:a" {
+		t.Errorf("server keys = %v, want [fa:results:a]", keys)
+	}
+
+	if _, ok := store.Get("results:missing"); ok {
+		t.Error("Get of a missing key reported a hit")
+	}
+
+	store.Delete("results:a")
+	if _, ok := store.Get("results:a"); ok {
+		t.Error("Get after Delete reported a hit")
+	}
+
+	stats := store.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.TotalRequests != 3 || stats.Errors != 0 {
+		t.Errorf("Stats = %+v, want 1 hit, 2 misses, 3 requests, no errors", stats)
+	}
+}
+
+func TestRedisStoreExpiry(t *testing.T) {
+	server := newFakeRedis(t, "")
+	store := newTestRedisStore(t, server, "")
+
+	store.Set("results:a", []byte("x"), 30*time.Millisecond)
+	if _, ok := store.Get("results:a"); !ok {
+		t.Fatal("Get before the TTL reported a miss")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := store.Get("results:a"); ok {
+		t.Error("Get after the TTL reported a hit")
+	}
+}
+
+func TestRedisStoreAuthFailure(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+
+	if _, err := NewRedisStore(RedisConfig{Addr: server.addr, Password: "wrong", Timeout: time.Second}); err == nil {
+		t.Error("NewRedisStore with a wrong password succeeded")
+	}
+	if _, err := NewRedisStore(RedisConfig{Addr: server.addr, Timeout: time.Second}); err == nil {
+		t.Error("NewRedisStore without a password succeeded")
+	}
+}
+
+func TestRedisStoreErrorReplyKeepsConnection(t *testing.T) {
+	server := newFakeRedis(t, "")
+	store := newTestRedisStore(t, server, "")
+
+	if _, err := store.do("NOSUCHCOMMAND"); err == nil {
+		t.Fatal("unknown command did not return an error")
+	} else if _, ok := err.(redisError); !ok {
+		t.Fatalf("error = %T %v, want redisError", err, err)
+	}
+
+	// The connection is still in sync after an error reply
+	store.Set("results:a", []byte("x"), time.Minute)
+	if got, ok := store.Get("results:a"); !ok || string(got) != "x" {
+		t.Errorf("Get after an error reply = %q, %v; want x, true", got, ok)
+	}
+}
+
+func TestRedisStoreDeleteMatchingPaginates(t *testing.T) {
+	server := newFakeRedis(t, "")
+	store := newTestRedisStore(t, server, "")
+
+	// Several SCAN pages of keys, interleaved with another application's keys
+	for i := 0; i < 250; i++ {
+		provider := "Garuda"
+		if i%2 == 1 {
+			provider = "Lion"
+		}
+		store.Set(fmt.Sprintf("results:%s:CGK:DPS:2025-12-%03d:1:economy", provider, i), []byte("[]"), time.Minute)
+		server.put(fmt.Sprintf("other:results:Garuda:%03d", i), "x")
+	}
+
+	deleted := store.DeleteMatching(func(key string) bool {
+		return strings.HasPrefix(key, "results:Garuda:")
+	})
+	if deleted != 125 {
+		t.Errorf("DeleteMatching deleted %d keys, want 125", deleted)
+	}
+
+	for _, key := range server.keys() {
+		if strings.HasPrefix(key, "fa:results:Garuda:") {
+			t.Fatalf("matching key %s was not deleted", key)
+		}
+	}
+	if got := len(server.keys()); got != 375 {
+		t.Errorf("%d keys left, want 375 (125 unmatched and 250 unprefixed)", got)
+	}
+}
+
+func TestRedisStoreStatsCountsPrefixedKeys(t *testing.T) {
+	server := newFakeRedis(t, "")
+	store := newTestRedisStore(t, server, "")
+
+	for i := 0; i < 150; i++ {
+		store.Set(fmt.Sprintf("results:%03d", i), []byte("[]"), time.Minute)
+	}
+	for i := 0; i < 40; i++ {
+		server.put(fmt.Sprintf("other:%03d", i), "x")
+	}
+
+	if size := store.Stats().CurrentSize; size != 150 {
+		t.Errorf("CurrentSize = %d, want 150 prefixed keys", size)
+	}
+}
+
+func TestRedisStorePeekRecordsNoStats(t *testing.T) {
+	server := newFakeRedis(t, "")
+	store := newTestRedisStore(t, server, "")
+
+	store.Set("results:a", []byte("x"), time.Minute)
+	if got, ok := store.Peek("results:a"); !ok || string(got) != "x" {
+		t.Fatalf("Peek = %q, %v; want x, true", got, ok)
+	}
+	if _, ok := store.Peek("results:missing"); ok {
+		t.Error("Peek of a missing key reported a hit")
+	}
+
+	if stats := store.Stats(); stats.Hits != 0 || stats.Misses != 0 || stats.TotalRequests != 0 {
+		t.Errorf("Stats = %+v, want no hits, misses or requests", stats)
+	}
+}
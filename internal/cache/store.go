@@ -0,0 +1,40 @@
+package cache
+
+import "time"
+
+// Store is a cache backend holding byte values with a per-entry TTL
+// Implementations must be safe for concurrent use. Backend errors are logged
+// and treated as cache misses.
+type Store interface {
+	// Get returns the value for key, or false if it is missing or expired
+	Get(key string) ([]byte, bool)
+	// Peek returns the value for key like Get, without counting a hit or miss or
+	// marking the entry as recently used
+	Peek(key string) ([]byte, bool)
+	// Set stores value under key for ttl
+	Set(key string, value []byte, ttl time.Duration)
+	// Delete removes key
+	Delete(key string)
+	// DeleteMatching removes every key for which match returns true and returns the count
+	DeleteMatching(match func(key string) bool) int
+	// Stats returns a snapshot of the store's statistics
+	Stats() Stats
+}
+
+// Stats tracks cache performance metrics
+type Stats struct {
+	Backend       string `json:"backend"`
+	Hits          int64  `json:"hits"`
+	Misses        int64  `json:"misses"`
+	Evictions     int64  `json:"evictions"`   // entries removed to stay within the limits
+	Expirations   int64  `json:"expirations"` // entries removed after their TTL
+	Errors        int64  `json:"errors"`      // backend errors, treated as misses
+	StaleHits     int64  `json:"stale_hits"`  // past their TTL but served within the stale window
+	Refreshes     int64  `json:"refreshes"`   // successful background refreshes of stale entries
+	RefreshErrors int64  `json:"refresh_errors"`
+	CurrentSize   int    `json:"current_size"`
+	CurrentBytes  int64  `json:"current_bytes"` // approximate
+	MaxEntries    int    `json:"max_entries"`
+	MaxBytes      int64  `json:"max_bytes"`
+	TotalRequests int64  `json:"total_requests"`
+}
@@ -2,6 +2,7 @@ package filter
 
 import (
 	"flight-aggregator/internal/models"
+	"strings"
 )
 
 // FilterEngine handles filtering of flight results
@@ -21,6 +22,11 @@ func (f *FilterEngine) Apply(flights []models.Flight, filters models.FilterOptio
 		result = f.filterByPrice(result, filters.MinPrice, filters.MaxPrice)
 	}
 
+	// Apply airline filter
+	if len(filters.Airlines) > 0 {
+		result = f.filterByAirlines(result, filters.Airlines)
+	}
+
 	// Apply stops filter
 	if filters.MaxStops != nil {
 		result = f.filterByStops(result, *filters.MaxStops)
@@ -41,6 +47,21 @@ func (f *FilterEngine) Apply(flights []models.Flight, filters models.FilterOptio
 		result = f.filterByDuration(result, *filters.MaxDuration)
 	}
 
+	// Apply amenities filter
+	if len(filters.RequiredAmenities) > 0 {
+		result = f.filterByAmenities(result, filters.RequiredAmenities)
+	}
+
+	// Apply aircraft family filter
+	if len(filters.AircraftFamilies) > 0 {
+		result = f.filterByAircraftFamily(result, filters.AircraftFamilies)
+	}
+
+	// Exclude turboprop aircraft
+	if filters.ExcludeTurboprops {
+		result = f.filterOutTurboprops(result)
+	}
+
 	return result
 }
 
@@ -67,6 +88,24 @@ func (f *FilterEngine) filterByPrice(flights []models.Flight, minPrice, maxPrice
 	return filtered
 }
 
+// filterByAirlines filters flights operated by one of the given airlines
+// Airlines are matched by IATA code or name (case-insensitive)
+func (f *FilterEngine) filterByAirlines(flights []models.Flight, airlines []string) []models.Flight {
+	filtered := make([]models.Flight, 0)
+
+	for _, flight := range flights {
+		for _, airline := range airlines {
+			airline = strings.TrimSpace(airline)
+			if strings.EqualFold(flight.Airline.Code, airline) || strings.EqualFold(flight.Airline.Name, airline) {
+				filtered = append(filtered, flight)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
 // filterByStops filters flights by maximum number of stops
 func (f *FilterEngine) filterByStops(flights []models.Flight, maxStops int) []models.Flight {
 	filtered := make([]models.Flight, 0)
@@ -124,3 +163,65 @@ func (f *FilterEngine) filterByDuration(flights []models.Flight, maxDuration int
 
 	return filtered
 }
+
+// filterByAmenities filters flights that offer all of the required amenities
+func (f *FilterEngine) filterByAmenities(flights []models.Flight, required []models.Amenity) []models.Flight {
+	filtered := make([]models.Flight, 0)
+
+	for _, flight := range flights {
+		offered := make(map[models.Amenity]bool, len(flight.Amenities))
+		for _, amenity := range flight.Amenities {
+			offered[amenity] = true
+		}
+
+		hasAll := true
+		for _, amenity := range required {
+			if !offered[amenity] {
+				hasAll = false
+				break
+			}
+		}
+
+		if hasAll {
+			filtered = append(filtered, flight)
+		}
+	}
+
+	return filtered
+}
+
+// filterByAircraftFamily filters flights operated by one of the given aircraft families
+// Flights with an unknown aircraft type are excluded
+func (f *FilterEngine) filterByAircraftFamily(flights []models.Flight, families []string) []models.Flight {
+	filtered := make([]models.Flight, 0)
+
+	for _, flight := range flights {
+		if flight.AircraftInfo == nil {
+			continue
+		}
+
+		for _, family := range families {
+			if strings.EqualFold(flight.AircraftInfo.Family, family) {
+				filtered = append(filtered, flight)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+// filterOutTurboprops removes flights operated by turboprop aircraft
+// Flights with an unknown aircraft type are kept
+func (f *FilterEngine) filterOutTurboprops(flights []models.Flight) []models.Flight {
+	filtered := make([]models.Flight, 0)
+
+	for _, flight := range flights {
+		if flight.AircraftInfo != nil && flight.AircraftInfo.BodyType == models.BodyTypeTurboprop {
+			continue
+		}
+		filtered = append(filtered, flight)
+	}
+
+	return filtered
+}
@@ -0,0 +1,99 @@
+package models
+
+import "strings"
+
+// BodyType represents the aircraft body category
+type BodyType string
+
+// Aircraft body types
+const (
+	BodyTypeNarrow    BodyType = "narrow"
+	BodyTypeWide      BodyType = "wide"
+	BodyTypeTurboprop BodyType = "turboprop"
+)
+
+// AircraftInfo represents normalized aircraft type details
+type AircraftInfo struct {
+	ICAOCode string   `json:"icao_code"`
+	Family   string   `json:"family"`
+	BodyType BodyType `json:"body_type"`
+}
+
+// aircraftType is an entry in the aircraft reference table
+type aircraftType struct {
+	info    AircraftInfo
+	aliases []string // provider spellings, matched after normalizeAircraftName
+}
+
+// aircraftTypes is the aircraft reference table
+var aircraftTypes = []aircraftType{
+	{AircraftInfo{"A319", "A320", BodyTypeNarrow}, []string{"Airbus A319", "A319"}},
+	{AircraftInfo{"A320", "A320", BodyTypeNarrow}, []string{"Airbus A320", "A320", "A320-200"}},
+	{AircraftInfo{"A20N", "A320", BodyTypeNarrow}, []string{"Airbus A320neo", "A320neo"}},
+	{AircraftInfo{"A321", "A320", BodyTypeNarrow}, []string{"Airbus A321", "A321"}},
+	{AircraftInfo{"A21N", "A320", BodyTypeNarrow}, []string{"Airbus A321neo", "A321neo"}},
+	{AircraftInfo{"A332", "A330", BodyTypeWide}, []string{"Airbus A330-200", "A330-200"}},
+	{AircraftInfo{"A333", "A330", BodyTypeWide}, []string{"Airbus A330-300", "A330-300", "Airbus A330", "A330"}},
+	{AircraftInfo{"A339", "A330", BodyTypeWide}, []string{"Airbus A330-900", "Airbus A330-900neo", "A330-900"}},
+	{AircraftInfo{"B737", "737", BodyTypeNarrow}, []string{"Boeing 737", "Boeing 737-700", "737-700", "737"}},
+	{AircraftInfo{"B738", "737", BodyTypeNarrow}, []string{"Boeing 737-800", "737-800"}},
+	{AircraftInfo{"B739", "737", BodyTypeNarrow}, []string{"Boeing 737-900", "Boeing 737-900ER", "737-900", "737-900ER"}},
+	{AircraftInfo{"B38M", "737", BodyTypeNarrow}, []string{"Boeing 737 MAX 8", "737 MAX 8", "737-8"}},
+	{AircraftInfo{"B77W", "777", BodyTypeWide}, []string{"Boeing 777-300ER", "777-300ER"}},
+	{AircraftInfo{"B789", "787", BodyTypeWide}, []string{"Boeing 787-9", "787-9"}},
+	{AircraftInfo{"CRJX", "CRJ", BodyTypeNarrow}, []string{"Bombardier CRJ1000", "CRJ1000", "CRJ-1000"}},
+	{AircraftInfo{"AT76", "ATR 72", BodyTypeTurboprop}, []string{"ATR 72-600", "ATR 72", "ATR72", "ATR 72-500"}},
+	{AircraftInfo{"AT46", "ATR 42", BodyTypeTurboprop}, []string{"ATR 42-600", "ATR 42", "ATR42"}},
+	{AircraftInfo{"DH8D", "Dash 8", BodyTypeTurboprop}, []string{"Bombardier Q400", "De Havilland Dash 8-400", "Dash 8-400", "Q400"}},
+}
+
+// aircraftIndex maps normalized names and ICAO codes to aircraft info
+var aircraftIndex = buildAircraftIndex()
+
+func buildAircraftIndex() map[string]AircraftInfo {
+	index := make(map[string]AircraftInfo)
+	for _, t := range aircraftTypes {
+		index[normalizeAircraftName(t.info.ICAOCode)] = t.info
+		for _, alias := range t.aliases {
+			index[normalizeAircraftName(alias)] = t.info
+		}
+	}
+	return index
+}
+
+// normalizeAircraftName lowercases and strips spaces and hyphens
+// Example: "Boeing 737-900ER" -> "boeing737900er"
+func normalizeAircraftName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r == ' ' || r == '-' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// LookupAircraft returns normalized details for a provider aircraft name or ICAO code
+// Returns (nil, false) if the aircraft is not in the reference table
+func LookupAircraft(name string) (*AircraftInfo, bool) {
+	if name == "" {
+		return nil, false
+	}
+
+	info, ok := aircraftIndex[normalizeAircraftName(name)]
+	if !ok {
+		return nil, false
+	}
+	return &info, true
+}
+
+// IsKnownAircraftFamily checks if the family exists in the reference table (case-insensitive)
+func IsKnownAircraftFamily(family string) bool {
+	for _, t := range aircraftTypes {
+		if strings.EqualFold(t.info.Family, family) {
+			return true
+		}
+	}
+	return false
+}
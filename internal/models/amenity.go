@@ -0,0 +1,32 @@
+package models
+
+// Amenity represents a canonical onboard amenity shared across all providers
+type Amenity string
+
+// Canonical amenities
+const (
+	AmenityWifi          Amenity = "wifi"
+	AmenityMeal          Amenity = "meal"
+	AmenitySnack         Amenity = "snack"
+	AmenityEntertainment Amenity = "entertainment"
+	AmenityPower         Amenity = "power"
+)
+
+// AllAmenities lists every canonical amenity in display order
+var AllAmenities = []Amenity{
+	AmenityWifi,
+	AmenityMeal,
+	AmenitySnack,
+	AmenityEntertainment,
+	AmenityPower,
+}
+
+// IsValid checks if the amenity is one of the canonical values
+func (a Amenity) IsValid() bool {
+	for _, known := range AllAmenities {
+		if a == known {
+			return true
+		}
+	}
+	return false
+}
@@ -18,8 +18,17 @@ type Flight struct {
 	CabinClass     string         `json:"cabin_class"`
 	AvailableSeats int            `json:"available_seats"`
 	Aircraft       string         `json:"aircraft"`
-	Amenities      []string       `json:"amenities"`
+	AircraftInfo   *AircraftInfo  `json:"aircraft_info,omitempty"`
+	Amenities      []Amenity      `json:"amenities"`
 	Baggage        BaggageInfo    `json:"baggage"`
+	Alternates     []FlightOffer  `json:"alternate_offers,omitempty"`
+}
+
+// FlightOffer represents another provider's offer for the same physical flight
+type FlightOffer struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	Price    Money  `json:"price"`
 }
 
 // Airline represents airline information
@@ -55,3 +64,18 @@ type BaggageInfo struct {
 	CarryOn string `json:"carry_on"`
 	Checked string `json:"checked"`
 }
+
+// Clone returns a deep copy of the flight that shares no slices or pointers with f
+func (f Flight) Clone() Flight {
+	if f.AircraftInfo != nil {
+		info := *f.AircraftInfo
+		f.AircraftInfo = &info
+	}
+	if f.Amenities != nil {
+		f.Amenities = append([]Amenity(nil), f.Amenities...)
+	}
+	if f.Alternates != nil {
+		f.Alternates = append([]FlightOffer(nil), f.Alternates...)
+	}
+	return f
+}
@@ -13,3 +13,30 @@ type AggregatedResults struct {
 	ProviderResults map[string]int    // provider name -> count of flights
 	ProviderErrors  map[string]string // provider name -> error message
 }
+
+// ProviderStatus represents a provider and its current availability
+type ProviderStatus struct {
+	Name         string    `json:"name"`
+	Carriers     []Airline `json:"carriers"`
+	CircuitState string    `json:"circuit_state"`
+	P95LatencyMs int       `json:"p95_latency_ms"`
+	Hedges       int64     `json:"hedges"`     // hedge requests launched
+	HedgeWins    int64     `json:"hedge_wins"` // hedge requests that returned first
+}
+
+// Trip legs reported in streamed provider updates
+const (
+	LegOutbound = "outbound"
+	LegReturn   = "return"
+)
+
+// ProviderUpdate represents a single provider's results streamed during a search
+type ProviderUpdate struct {
+	Leg        string   `json:"leg"` // "outbound" or "return"
+	Provider   string   `json:"provider"`
+	Flights    []Flight `json:"flights"`
+	Error      string   `json:"error,omitempty"`
+	DurationMs int      `json:"duration_ms"`
+	CacheHit   bool     `json:"cache_hit"` // served from the cache without querying the provider
+	Stale      bool     `json:"stale"`     // cached results past their TTL, refreshed in the background
+}
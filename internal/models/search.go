@@ -1,12 +1,41 @@
 package models
 
+import "time"
+
 // ErrorResponse represents an API error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-	Code    int    `json:"code"`
+	Error   string `json:"error"`             // short human-readable error type
+	Code    string `json:"code"`              // stable machine-readable error code (ErrorCode* constants)
+	Message string `json:"message,omitempty"` // details
+	Field   string `json:"field,omitempty"`   // first offending request field or query parameter, for validation errors
+	Status  int    `json:"status"`            // HTTP status code
+
+	Errors []FieldError `json:"errors,omitempty"` // every invalid field, for validation errors
+}
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`   // JSON path of the field, or the query parameter for GET requests
+	Code    string `json:"code"`    // "required", "invalid_format", "invalid_value" or "out_of_range"
+	Message string `json:"message"` // human-readable description
 }
 
+// Error codes reported in ErrorResponse.Code
+const (
+	ErrorCodeInvalidRequest     = "invalid_request"      // malformed body, message or parameters
+	ErrorCodeValidation         = "validation_error"     // a field failed validation; see Field
+	ErrorCodeNoProviders        = "no_providers"         // no provider serves the route or sells the requested airlines
+	ErrorCodeNoResults          = "no_results"           // providers answered but found no flights
+	ErrorCodeAllProvidersFailed = "all_providers_failed" // every queried provider failed
+	ErrorCodeTimeout            = "timeout"              // the search or every provider timed out
+	ErrorCodeRateLimited        = "rate_limited"         // too many requests from this client
+	ErrorCodeSessionNotFound    = "session_not_found"    // live search session expired or unknown
+	ErrorCodeUnauthorized       = "unauthorized"         // missing or invalid admin API key
+	ErrorCodeAdminDisabled      = "admin_disabled"       // no admin API key is configured
+	ErrorCodeConflict           = "conflict"             // the request conflicts with the current state
+	ErrorCodeInternal           = "internal_error"       // unexpected server error
+)
+
 // SearchRequest represents a flight search request
 type SearchRequest struct {
 	Origin          string         `json:"origin" validate:"required,len=3"`
@@ -32,6 +61,10 @@ type FilterOptions struct {
 	DepartureTime *TimeRange `json:"departureTime,omitempty"`
 	ArrivalTime   *TimeRange `json:"arrivalTime,omitempty"`
 	MaxDuration   *int       `json:"maxDuration,omitempty"` // minutes
+
+	RequiredAmenities []Amenity `json:"requiredAmenities,omitempty"`
+	AircraftFamilies  []string  `json:"aircraftFamilies,omitempty"`
+	ExcludeTurboprops bool      `json:"excludeTurboprops,omitempty"`
 }
 
 // TimeRange represents a time range filter (hours in 24-hour format)
@@ -67,8 +100,30 @@ type SearchMetadata struct {
 	ProvidersQueried   int               `json:"providers_queried"`
 	ProvidersSucceeded int               `json:"providers_succeeded"`
 	ProvidersFailed    int               `json:"providers_failed"`
+	ProvidersSkipped   int               `json:"providers_skipped"`
+	ProvidersPending   int               `json:"providers_pending"`
 	SearchTimeMs       int               `json:"search_time_ms"`
-	CacheHit           bool              `json:"cache_hit"`
+	CacheHit           bool              `json:"cache_hit"`            // every provider was served from the cache
+	Stale              bool              `json:"stale"`                // some cached results were past their TTL and are being refreshed
+	Coalesced          bool              `json:"coalesced"`            // provider results shared with a concurrent identical search
+	CachedAt           *time.Time        `json:"cached_at,omitempty"`  // when the oldest provider results were fetched, i.e. fares last confirmed
+	AgeSeconds         int               `json:"age_seconds"`          // seconds since cached_at
+	ExpiresAt          *time.Time        `json:"expires_at,omitempty"` // when the earliest cached provider results go stale; omitted if some are not cached
+	DuplicatesMerged   int               `json:"duplicates_merged"`
 	ProviderResults    map[string]int    `json:"provider_results,omitempty"`
 	ProviderErrors     map[string]string `json:"provider_errors,omitempty"`
+	ProviderSkipped    map[string]string `json:"provider_skipped,omitempty"`
+	ProviderPending    []string          `json:"provider_pending,omitempty"`
+	ProviderCache      map[string]string `json:"provider_cache,omitempty"` // provider name -> "hit", "stale" or "miss"
+}
+
+// SessionRefinement represents new filters and sort options for a live search session
+// Refinements replace the session's previous filters and sort options
+type SessionRefinement struct {
+	Filters         *FilterOptions `json:"filters,omitempty"`
+	SortBy          string         `json:"sortBy,omitempty"`
+	SortOrder       string         `json:"sortOrder,omitempty"`
+	ReturnFilters   *FilterOptions `json:"returnFilters,omitempty"`
+	ReturnSortBy    string         `json:"returnSortBy,omitempty"`
+	ReturnSortOrder string         `json:"returnSortOrder,omitempty"`
 }
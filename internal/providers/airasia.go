@@ -16,6 +16,14 @@ type AirAsiaProvider struct {
 
 // NewAirAsiaProviderFromConfig creates a new AirAsia provider from config
 func NewAirAsiaProviderFromConfig(cfg ProviderConfig) *AirAsiaProvider {
+	cfg = withDataDefaults(cfg, models.Airline{Code: "QZ", Name: "AirAsia"}, func(response AirAsiaResponse) []string {
+		routes := make([]string, 0, len(response.Flights))
+		for _, af := range response.Flights {
+			routes = append(routes, RouteKey(af.FromAirport, af.ToAirport))
+		}
+		return routes
+	})
+
 	return &AirAsiaProvider{
 		BaseProvider: NewBaseProviderFromConfig(cfg),
 	}
@@ -166,7 +174,7 @@ func (a *AirAsiaProvider) convertToFlight(af AirAsiaFlight) (models.Flight, erro
 		},
 		CabinClass:     af.CabinClass,
 		AvailableSeats: af.Seats,
-		Amenities:      []string{},
+		Amenities:      []models.Amenity{}, // AirAsia does not report onboard amenities
 		Baggage: models.BaggageInfo{
 			CarryOn: carryOn,
 			Checked: checked,
@@ -0,0 +1,45 @@
+package providers
+
+import (
+	"flight-aggregator/internal/models"
+	"strings"
+)
+
+// amenityAliases maps provider-specific amenity labels (lowercased) to canonical amenities
+var amenityAliases = map[string]models.Amenity{
+	"wifi":                   models.AmenityWifi,
+	"wi-fi":                  models.AmenityWifi,
+	"internet":               models.AmenityWifi,
+	"meal":                   models.AmenityMeal,
+	"meals":                  models.AmenityMeal,
+	"hot meal":               models.AmenityMeal,
+	"snack":                  models.AmenitySnack,
+	"snacks":                 models.AmenitySnack,
+	"entertainment":          models.AmenityEntertainment,
+	"inflight_entertainment": models.AmenityEntertainment,
+	"ife":                    models.AmenityEntertainment,
+	"power":                  models.AmenityPower,
+	"power_outlet":           models.AmenityPower,
+	"usb":                    models.AmenityPower,
+}
+
+// normalizeAmenities converts provider amenity labels to canonical amenities.
+// Unknown labels (e.g. "Beverage") are dropped and duplicates are removed.
+// The result is ordered as in models.AllAmenities.
+func normalizeAmenities(raw []string) []models.Amenity {
+	present := make(map[models.Amenity]bool)
+	for _, label := range raw {
+		if amenity, ok := amenityAliases[strings.ToLower(strings.TrimSpace(label))]; ok {
+			present[amenity] = true
+		}
+	}
+
+	amenities := make([]models.Amenity, 0, len(present))
+	for _, amenity := range models.AllAmenities {
+		if present[amenity] {
+			amenities = append(amenities, amenity)
+		}
+	}
+
+	return amenities
+}
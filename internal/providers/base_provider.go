@@ -2,8 +2,10 @@ package providers
 
 import (
 	"context"
+	"flight-aggregator/internal/models"
 	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 )
 
@@ -13,6 +15,8 @@ type ProviderConfig struct {
 	ResponseTime time.Duration
 	FailureRate  float64
 	DataPath     string
+	Carriers     []models.Airline // airlines sold by this provider
+	Routes       []string         // routes served, e.g. "CGK-DPS"; empty means all routes
 }
 
 // BaseProvider contains common functionality for all providers
@@ -21,6 +25,8 @@ type BaseProvider struct {
 	responseDelay time.Duration
 	failureRate   float64 // 0.0 to 1.0 (0% to 100%)
 	mockDataPath  string
+	carriers      []models.Airline
+	routes        map[string]bool // nil means all routes are served
 }
 
 // NewBaseProviderFromConfig creates a new BaseProvider from config
@@ -30,14 +36,65 @@ func NewBaseProviderFromConfig(cfg ProviderConfig) BaseProvider {
 		responseDelay: cfg.ResponseTime,
 		failureRate:   cfg.FailureRate,
 		mockDataPath:  cfg.DataPath,
+		carriers:      cfg.Carriers,
+		routes:        buildRouteSet(cfg.Routes),
 	}
 }
 
+// withDataDefaults fills in the carriers and routes cfg leaves unset from the provider's
+// own data: carrier is the airline the data is for, and flightRoutes lists the route of
+// each flight in the data, decoded as R. Routes stay unset, serving every route, if the
+// data cannot be loaded.
+func withDataDefaults[R any](cfg ProviderConfig, carrier models.Airline, flightRoutes func(R) []string) ProviderConfig {
+	if len(cfg.Carriers) == 0 {
+		cfg.Carriers = []models.Airline{carrier}
+	}
+
+	if len(cfg.Routes) == 0 {
+		var response R
+		if err := LoadMockData(cfg.DataPath, &response); err == nil {
+			cfg.Routes = flightRoutes(response)
+		}
+	}
+	return cfg
+}
+
+// buildRouteSet creates a lookup set from route strings such as "CGK-DPS"
+func buildRouteSet(routes []string) map[string]bool {
+	if len(routes) == 0 {
+		return nil
+	}
+
+	set := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		set[strings.ToUpper(strings.TrimSpace(route))] = true
+	}
+	return set
+}
+
+// RouteKey formats an origin/destination pair as a route string (e.g. "CGK-DPS")
+func RouteKey(origin, destination string) string {
+	return strings.ToUpper(origin) + "-" + strings.ToUpper(destination)
+}
+
 // Name returns the provider name
 func (b *BaseProvider) Name() string {
 	return b.name
 }
 
+// Carriers returns the airlines sold by the provider
+func (b *BaseProvider) Carriers() []models.Airline {
+	return b.carriers
+}
+
+// ServesRoute returns true if the provider flies between origin and destination
+func (b *BaseProvider) ServesRoute(origin, destination string) bool {
+	if b.routes == nil {
+		return true
+	}
+	return b.routes[RouteKey(origin, destination)]
+}
+
 // HealthCheck returns true if the provider is healthy
 func (b *BaseProvider) HealthCheck() bool {
 	// Simple health check - in real implementation, this would check actual API availability
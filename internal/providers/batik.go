@@ -16,6 +16,14 @@ type BatikProvider struct {
 
 // NewBatikProviderFromConfig creates a new Batik Air provider from config
 func NewBatikProviderFromConfig(cfg ProviderConfig) *BatikProvider {
+	cfg = withDataDefaults(cfg, models.Airline{Code: "ID", Name: "Batik Air"}, func(response BatikResponse) []string {
+		routes := make([]string, 0, len(response.Results))
+		for _, bf := range response.Results {
+			routes = append(routes, RouteKey(bf.Origin, bf.Destination))
+		}
+		return routes
+	})
+
 	return &BatikProvider{
 		BaseProvider: NewBaseProviderFromConfig(cfg),
 	}
@@ -135,9 +143,8 @@ func (b *BatikProvider) convertToFlight(bf BatikFlight) (models.Flight, error) {
 	// Create flight object with unique ID
 	flightID := fmt.Sprintf("%s_%s", bf.FlightNumber, b.Name())
 
-	// Build amenities list
-	amenities := make([]string, 0)
-	amenities = append(amenities, bf.OnboardServices...)
+	// Normalize aircraft type against the reference table
+	aircraftInfo, _ := models.LookupAircraft(bf.AircraftModel)
 
 	flight := models.Flight{
 		ID:           flightID,
@@ -172,8 +179,9 @@ func (b *BatikProvider) convertToFlight(bf BatikFlight) (models.Flight, error) {
 		},
 		CabinClass:     bf.Fare.Class,
 		Aircraft:       bf.AircraftModel,
+		AircraftInfo:   aircraftInfo,
 		AvailableSeats: bf.SeatsAvailable,
-		Amenities:      amenities,
+		Amenities:      normalizeAmenities(bf.OnboardServices),
 		Baggage: models.BaggageInfo{
 			CarryOn: carryOn,
 			Checked: checked,
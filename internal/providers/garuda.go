@@ -15,6 +15,14 @@ type GarudaProvider struct {
 
 // NewGarudaProviderFromConfig creates a new Garuda Indonesia provider from config
 func NewGarudaProviderFromConfig(cfg ProviderConfig) *GarudaProvider {
+	cfg = withDataDefaults(cfg, models.Airline{Code: "GA", Name: "Garuda Indonesia"}, func(response GarudaResponse) []string {
+		routes := make([]string, 0, len(response.Flights))
+		for _, gf := range response.Flights {
+			routes = append(routes, RouteKey(gf.Departure.Airport, gf.Arrival.Airport))
+		}
+		return routes
+	})
+
 	return &GarudaProvider{
 		BaseProvider: NewBaseProviderFromConfig(cfg),
 	}
@@ -125,6 +133,9 @@ func (g *GarudaProvider) convertToFlight(gf GarudaFlight) (models.Flight, error)
 	// Create flight object with unique ID
 	flightID := fmt.Sprintf("%s_%s", gf.FlightID, g.Name())
 
+	// Normalize aircraft type against the reference table
+	aircraftInfo, _ := models.LookupAircraft(gf.Aircraft)
+
 	flight := models.Flight{
 		ID:           flightID,
 		Provider:     g.Name(),
@@ -158,8 +169,9 @@ func (g *GarudaProvider) convertToFlight(gf GarudaFlight) (models.Flight, error)
 		},
 		CabinClass:     gf.FareClass,
 		Aircraft:       gf.Aircraft,
+		AircraftInfo:   aircraftInfo,
 		AvailableSeats: gf.AvailableSeats,
-		Amenities:      gf.Amenities,
+		Amenities:      normalizeAmenities(gf.Amenities),
 		Baggage: models.BaggageInfo{
 			CarryOn: carryOnText,
 			Checked: checkedText,
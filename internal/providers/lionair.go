@@ -15,6 +15,14 @@ type LionAirProvider struct {
 
 // NewLionAirProviderFromConfig creates a new Lion Air provider from config
 func NewLionAirProviderFromConfig(cfg ProviderConfig) *LionAirProvider {
+	cfg = withDataDefaults(cfg, models.Airline{Code: "JT", Name: "Lion Air"}, func(response LionAirResponse) []string {
+		routes := make([]string, 0, len(response.Data.AvailableFlights))
+		for _, lf := range response.Data.AvailableFlights {
+			routes = append(routes, RouteKey(lf.Route.From.Code, lf.Route.To.Code))
+		}
+		return routes
+	})
+
 	return &LionAirProvider{
 		BaseProvider: NewBaseProviderFromConfig(cfg),
 	}
@@ -124,14 +132,17 @@ func (l *LionAirProvider) convertToFlight(lf LionAirFlight) (models.Flight, erro
 	durationMinutes := lf.FlightTime
 
 	// Build amenities list
-	amenities := make([]string, 0)
+	amenities := make([]models.Amenity, 0)
 	if lf.Services.WifiAvailable {
-		amenities = append(amenities, "WiFi")
+		amenities = append(amenities, models.AmenityWifi)
 	}
 	if lf.Services.MealsIncluded {
-		amenities = append(amenities, "Meals")
+		amenities = append(amenities, models.AmenityMeal)
 	}
 
+	// Normalize aircraft type against the reference table
+	aircraftInfo, _ := models.LookupAircraft(lf.PlaneType)
+
 	flight := models.Flight{
 		ID:           flightID,
 		Provider:     l.Name(),
@@ -165,6 +176,7 @@ func (l *LionAirProvider) convertToFlight(lf LionAirFlight) (models.Flight, erro
 		},
 		CabinClass:     lf.Pricing.FareType,
 		Aircraft:       lf.PlaneType,
+		AircraftInfo:   aircraftInfo,
 		AvailableSeats: lf.SeatsLeft,
 		Amenities:      amenities,
 		Baggage: models.BaggageInfo{
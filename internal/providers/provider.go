@@ -10,6 +10,12 @@ type Provider interface {
 	// Name returns the provider name
 	Name() string
 
+	// Carriers returns the airlines (IATA code and name) sold by the provider
+	Carriers() []models.Airline
+
+	// ServesRoute returns true if the provider has flights between origin and destination
+	ServesRoute(origin, destination string) bool
+
 	// Search performs a flight search
 	Search(ctx context.Context, req models.SearchRequest) ([]models.Flight, error)
 
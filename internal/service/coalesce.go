@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"flight-aggregator/internal/aggregator"
+	"flight-aggregator/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sharedSearch holds the provider results of a fan-out shared by concurrent searches
+type sharedSearch struct {
+	aggregated *aggregator.AggregatedResults
+	err        error
+}
+
+// searchCoalesced queries providers for req, sharing one fan-out between concurrent
+// searches with the same coalesce key.
+// The fan-out is detached from ctx so one caller giving up does not fail the others;
+// it stays bounded by the aggregator timeout.
+func (s *SearchService) searchCoalesced(ctx context.Context, req models.SearchRequest) (*legResults, error) {
+	ch := s.inflight.DoChan(coalesceKey(req), func() (interface{}, error) {
+		aggregated, err := s.aggregator.SearchAll(context.WithoutCancel(ctx), req)
+		return &sharedSearch{aggregated: aggregated, err: err}, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-ch:
+		shared := result.Val.(*sharedSearch)
+		if shared.aggregated == nil {
+			return nil, shared.err
+		}
+
+		// Each caller gets its own copy so responses built from a shared fan-out
+		// never alias each other's flights or metadata maps
+		aggregated := shared.aggregated
+		if result.Shared {
+			aggregated = aggregated.Clone()
+		}
+		return &legResults{AggregatedResults: aggregated, coalesced: result.Shared}, shared.err
+	}
+}
+
+// coalesceKey identifies the provider-relevant part of a search request: the route,
+// date, passengers and cabin class, plus the airline filter since it selects providers.
+// Other filters and sorting are applied by each caller.
+func coalesceKey(req models.SearchRequest) string {
+	var airlines []string
+	if req.Filters != nil {
+		for _, airline := range req.Filters.Airlines {
+			airlines = append(airlines, strings.ToUpper(strings.TrimSpace(airline)))
+		}
+		sort.Strings(airlines)
+	}
+
+	return fmt.Sprintf("%s:%s:%s:%d:%s:%s",
+		strings.ToUpper(req.Origin),
+		strings.ToUpper(req.Destination),
+		req.DepartureDate,
+		req.Passengers,
+		strings.ToLower(req.CabinClass),
+		strings.Join(airlines, ","))
+}
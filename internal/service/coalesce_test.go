@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"flight-aggregator/internal/models"
+	"flight-aggregator/pkg/config"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// newTestService creates a search service over the mock provider data with the
+// in-memory cache backend
+func newTestService(t *testing.T) *SearchService {
+	t.Helper()
+
+	provider := func(name, file string) config.ProviderDetail {
+		return config.ProviderDetail{
+			Name:         name,
+			Enabled:      true,
+			ResponseTime: "200ms",
+			DataPath:     "../../test_data/" + file,
+		}
+	}
+
+	return NewSearchServiceWithConfig(&config.Config{
+		Cache: config.CacheConfig{TTL: "1m"},
+		Provider: config.ProviderConfig{
+			Timeout: "5s",
+			Providers: map[string]config.ProviderDetail{
+				"garuda":  provider("Garuda Indonesia", "garuda_indonesia_search_response.json"),
+				"lionair": provider("Lion Air", "lion_air_search_response.json"),
+				"batik":   provider("Batik Air", "batik_air_search_response.json"),
+				"airasia": provider("AirAsia", "airasia_search_response.json"),
+			},
+		},
+		Retry: config.RetryConfig{MaxAttempts: 1},
+	})
+}
+
+// mutateResponse changes every field of the response that could alias another
+// response's data, so the race detector reports any sharing
+func mutateResponse(resp *models.SearchResponse) {
+	for i := range resp.Flights {
+		flight := &resp.Flights[i]
+		flight.Price.Amount++
+		flight.Amenities = append(flight.Amenities[:0], models.AmenityWifi)
+		if flight.AircraftInfo != nil {
+			flight.AircraftInfo.Family = "mutated"
+		}
+		for j := range flight.Alternates {
+			flight.Alternates[j].Price.Amount++
+		}
+	}
+	if resp.BestValueFlight != nil {
+		resp.BestValueFlight.Price.Amount++
+	}
+	resp.Metadata.ProviderResults["mutated"] = 1
+	resp.Metadata.ProviderErrors["mutated"] = "mutated"
+	resp.Metadata.ProviderCache["mutated"] = "hit"
+}
+
+// prices lists the price of each flight of the response
+func prices(resp *models.SearchResponse) []float64 {
+	list := make([]float64, len(resp.Flights))
+	for i, flight := range resp.Flights {
+		list[i] = flight.Price.Amount
+	}
+	return list
+}
+
+// searchConcurrently runs n identical searches at once, mutating each response as soon
+// as it is returned. Each response's prices after its own mutation must be its prices
+// before it plus one; anything else means another search changed them.
+func searchConcurrently(t *testing.T, s *SearchService, req models.SearchRequest, n int) []*models.SearchResponse {
+	t.Helper()
+
+	responses := make([]*models.SearchResponse, n)
+	before := make([][]float64, n)
+	errs := make([]error, n)
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			resp, err := s.Search(context.Background(), req)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			before[i] = prices(resp)
+			mutateResponse(resp)
+			responses[i] = resp
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, resp := range responses {
+		if errs[i] != nil {
+			t.Fatalf("search %d failed: %v", i, errs[i])
+		}
+		if len(resp.Flights) == 0 {
+			t.Fatalf("search %d returned no flights", i)
+		}
+		if len(resp.Flights) != len(responses[0].Flights) {
+			t.Errorf("search %d returned %d flights, want %d", i, len(resp.Flights), len(responses[0].Flights))
+		}
+		for j, price := range prices(resp) {
+			if price != before[i][j]+1 {
+				t.Errorf("search %d flight %d: price %v after mutating %v, want %v", i, j, price, before[i][j], before[i][j]+1)
+			}
+		}
+	}
+	return responses
+}
+
+func TestSearchCoalescedResponsesDoNotAlias(t *testing.T) {
+	s := newTestService(t)
+	req := models.SearchRequest{
+		Origin:        "CGK",
+		Destination:   "DPS",
+		DepartureDate: "2025-12-15",
+		Passengers:    1,
+		CabinClass:    "economy",
+	}
+
+	const searches = 16
+
+	// First round: concurrent searches share one provider fan-out
+	coalesced := 0
+	for _, resp := range searchConcurrently(t, s, req, searches) {
+		if resp.Metadata.Coalesced {
+			coalesced++
+		}
+	}
+	if coalesced == 0 {
+		t.Error("no search shared a provider fan-out")
+	}
+
+	reference, err := s.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("reference search failed: %v", err)
+	}
+	want := prices(reference)
+
+	// Second round: every response is built from the cached provider results
+	for i, resp := range searchConcurrently(t, s, req, searches) {
+		if !resp.Metadata.CacheHit {
+			t.Errorf("search %d was not served from the cache: %v", i, resp.Metadata.ProviderCache)
+		}
+	}
+
+	// Mutated responses never leak into the cache
+	after, err := s.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("search after mutations failed: %v", err)
+	}
+	got := prices(after)
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("prices after mutating cached responses = %v, want %v", got, want)
+	}
+}
@@ -9,10 +9,13 @@ import (
 	"flight-aggregator/internal/providers"
 	"flight-aggregator/internal/ranking"
 	"flight-aggregator/internal/validator"
+	"flight-aggregator/pkg/circuitbreaker"
 	"flight-aggregator/pkg/config"
 	"flight-aggregator/pkg/retry"
 	"log"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // SearchService handles flight search orchestration
@@ -24,6 +27,14 @@ type SearchService struct {
 	sorter     *filter.Sorter
 	scorer     *ranking.Scorer
 	validator  *validator.Validator
+
+	// inflight coalesces concurrent provider fan-outs for the same search
+	inflight singleflight.Group
+
+	sessions *sessionStore // live search sessions
+
+	searchLog searchLog // recent user searches, for the cache warmer
+	warmer    *CacheWarmer
 }
 
 // NewSearchServiceWithConfig creates a new search service with config-based providers
@@ -34,45 +45,25 @@ func NewSearchServiceWithConfig(cfg *config.Config) *SearchService {
 	if garudaCfg, exists := cfg.Provider.GetProviderConfig("garuda"); exists && garudaCfg.Enabled {
 		log.Printf("Initializing provider: %s (delay: %v, failure rate: %.1f%%)",
 			garudaCfg.Name, garudaCfg.GetResponseTime(), garudaCfg.FailureRate*100)
-		providerList = append(providerList, providers.NewGarudaProviderFromConfig(providers.ProviderConfig{
-			Name:         garudaCfg.Name,
-			ResponseTime: garudaCfg.GetResponseTime(),
-			FailureRate:  garudaCfg.FailureRate,
-			DataPath:     garudaCfg.DataPath,
-		}))
+		providerList = append(providerList, providers.NewGarudaProviderFromConfig(newProviderConfig(garudaCfg)))
 	}
 
 	if lionairCfg, exists := cfg.Provider.GetProviderConfig("lionair"); exists && lionairCfg.Enabled {
 		log.Printf("Initializing provider: %s (delay: %v, failure rate: %.1f%%)",
 			lionairCfg.Name, lionairCfg.GetResponseTime(), lionairCfg.FailureRate*100)
-		providerList = append(providerList, providers.NewLionAirProviderFromConfig(providers.ProviderConfig{
-			Name:         lionairCfg.Name,
-			ResponseTime: lionairCfg.GetResponseTime(),
-			FailureRate:  lionairCfg.FailureRate,
-			DataPath:     lionairCfg.DataPath,
-		}))
+		providerList = append(providerList, providers.NewLionAirProviderFromConfig(newProviderConfig(lionairCfg)))
 	}
 
 	if batikCfg, exists := cfg.Provider.GetProviderConfig("batik"); exists && batikCfg.Enabled {
 		log.Printf("Initializing provider: %s (delay: %v, failure rate: %.1f%%)",
 			batikCfg.Name, batikCfg.GetResponseTime(), batikCfg.FailureRate*100)
-		providerList = append(providerList, providers.NewBatikProviderFromConfig(providers.ProviderConfig{
-			Name:         batikCfg.Name,
-			ResponseTime: batikCfg.GetResponseTime(),
-			FailureRate:  batikCfg.FailureRate,
-			DataPath:     batikCfg.DataPath,
-		}))
+		providerList = append(providerList, providers.NewBatikProviderFromConfig(newProviderConfig(batikCfg)))
 	}
 
 	if airAsiaCfg, exists := cfg.Provider.GetProviderConfig("airasia"); exists && airAsiaCfg.Enabled {
 		log.Printf("Initializing provider: %s (delay: %v, failure rate: %.1f%%)",
 			airAsiaCfg.Name, airAsiaCfg.GetResponseTime(), airAsiaCfg.FailureRate*100)
-		providerList = append(providerList, providers.NewAirAsiaProviderFromConfig(providers.ProviderConfig{
-			Name:         airAsiaCfg.Name,
-			ResponseTime: airAsiaCfg.GetResponseTime(),
-			FailureRate:  airAsiaCfg.FailureRate,
-			DataPath:     airAsiaCfg.DataPath,
-		}))
+		providerList = append(providerList, providers.NewAirAsiaProviderFromConfig(newProviderConfig(airAsiaCfg)))
 	}
 
 	log.Printf("Initialized %d providers from configuration", len(providerList))
@@ -86,19 +77,139 @@ func NewSearchServiceWithConfig(cfg *config.Config) *SearchService {
 	log.Printf("Retry configuration: max_attempts=%d, initial_delay=%v, max_delay=%v, multiplier=%.1f",
 		retryParams.MaxAttempts, retryParams.InitialDelay, retryParams.MaxDelay, retryParams.BackoffMultiplier)
 
-	return &SearchService{
+	// Create circuit breaker params from config
+	breakerParams := circuitbreaker.FromConfig(cfg.CircuitBreaker)
+	log.Printf("Circuit breaker configuration: failure_threshold=%d, cool_down=%v",
+		breakerParams.FailureThreshold, breakerParams.CoolDown)
+
+	resultCache := cache.New(newCacheStore(&cfg.Cache), cacheTTL)
+	if staleWindow := cfg.Cache.GetStaleWindow(); staleWindow > 0 {
+		resultCache.SetStaleWindow(staleWindow)
+		log.Printf("Cache stale window: %v (stale results served while refreshed in the background)", staleWindow)
+	}
+
+	agg := aggregator.NewAggregator(providerList, aggregatorTimeout, retryParams, breakerParams)
+	agg.SetResultCache(resultCache)
+	if softDeadline := cfg.Provider.GetSoftDeadline(); softDeadline > 0 {
+		agg.SetSoftDeadline(softDeadline)
+		log.Printf("Soft deadline: %v (partial results returned after this, late results cached)", softDeadline)
+	}
+
+	// Apply per-provider timeout and retry overrides
+	for _, detail := range cfg.Provider.Providers {
+		if !detail.Enabled {
+			continue
+		}
+		policy := newProviderPolicy(&detail, aggregatorTimeout, retryParams)
+		agg.SetProviderPolicy(detail.Name, policy)
+		log.Printf("Provider policy for %s: timeout=%v, max_attempts=%d, initial_delay=%v, max_delay=%v, multiplier=%.1f, hedge=%t, cache_ttl=%v",
+			detail.Name, policy.Timeout, policy.Retry.MaxAttempts, policy.Retry.InitialDelay, policy.Retry.MaxDelay, policy.Retry.BackoffMultiplier, policy.HedgeEnabled, policy.CacheTTL)
+	}
+
+	s := &SearchService{
 		providers:  providerList,
-		aggregator: aggregator.NewAggregator(providerList, aggregatorTimeout, retryParams),
-		cache:      cache.New(cacheTTL),
+		aggregator: agg,
+		cache:      resultCache,
 		filter:     filter.NewFilterEngine(),
 		sorter:     filter.NewSorter(),
 		scorer:     ranking.NewScorerFromConfig(cfg),
 		validator:  validator.NewValidator(),
+		sessions:   newSessionStore(cfg.Server.GetSessionIdleTimeout()),
 	}
+
+	s.warmer = newCacheWarmer(s, cfg.Cache.Warmer)
+	if cfg.Cache.Warmer.Enabled {
+		if err := s.warmer.Start(); err != nil {
+			log.Printf("Warning: failed to start cache warmer: %v", err)
+		}
+	}
+
+	return s
+}
+
+// newCacheStore creates the configured cache backend
+// Falls back to the in-memory store if the Redis server cannot be reached
+func newCacheStore(cfg *config.CacheConfig) cache.Store {
+	if cfg.Backend == "redis" {
+		store, err := cache.NewRedisStore(cache.RedisConfig{
+			Addr:      cfg.Redis.Addr,
+			Password:  cfg.Redis.Password,
+			DB:        cfg.Redis.DB,
+			KeyPrefix: cfg.Redis.KeyPrefix,
+			Timeout:   cfg.Redis.GetTimeout(),
+		})
+		if err == nil {
+			log.Printf("Cache configuration: backend=redis, addr=%s, db=%d, ttl=%s", cfg.Redis.Addr, cfg.Redis.DB, cfg.TTL)
+			return store
+		}
+		log.Printf("Warning: Redis cache unavailable, falling back to memory: %v", err)
+	}
+
+	log.Printf("Cache configuration: backend=memory, ttl=%s, max_entries=%d, max_memory_mb=%d",
+		cfg.TTL, cfg.MaxEntries, cfg.MaxMemoryMB)
+	return cache.NewMemoryStore(cfg.MaxEntries, cfg.GetMaxBytes())
+}
+
+// newProviderConfig converts provider settings from the config file into a providers.ProviderConfig
+func newProviderConfig(detail *config.ProviderDetail) providers.ProviderConfig {
+	carriers := make([]models.Airline, 0, len(detail.Carriers))
+	for _, c := range detail.Carriers {
+		carriers = append(carriers, models.Airline{Code: c.Code, Name: c.Name})
+	}
+
+	return providers.ProviderConfig{
+		Name:         detail.Name,
+		ResponseTime: detail.GetResponseTime(),
+		FailureRate:  detail.FailureRate,
+		DataPath:     detail.DataPath,
+		Carriers:     carriers,
+		Routes:       detail.Routes,
+	}
+}
+
+// newProviderPolicy builds a provider's timeout and retry policy, falling back to the global defaults
+func newProviderPolicy(detail *config.ProviderDetail, defaultTimeout time.Duration, defaultRetry retry.Params) aggregator.ProviderPolicy {
+	policy := aggregator.ProviderPolicy{
+		Timeout:      defaultTimeout,
+		Retry:        defaultRetry,
+		HedgeEnabled: detail.HedgeEnabled,
+		HedgeDelay:   detail.GetHedgeDelay(),
+		CacheTTL:     detail.GetCacheTTL(),
+	}
+
+	if timeout := detail.GetTimeout(); timeout > 0 {
+		policy.Timeout = timeout
+	}
+	if detail.MaxAttempts > 0 {
+		policy.Retry.MaxAttempts = detail.MaxAttempts
+	}
+	if delay := detail.GetInitialDelay(); delay > 0 {
+		policy.Retry.InitialDelay = delay
+	}
+	if delay := detail.GetMaxDelay(); delay > 0 {
+		policy.Retry.MaxDelay = delay
+	}
+	if detail.Multiplier > 0 {
+		policy.Retry.BackoffMultiplier = detail.Multiplier
+	}
+
+	return policy
 }
 
 // Search performs a flight search with full orchestration
 func (s *SearchService) Search(ctx context.Context, req models.SearchRequest) (*models.SearchResponse, error) {
+	return s.search(ctx, req, nil)
+}
+
+// SearchStream performs a flight search like Search, calling onUpdate with each
+// provider's filtered flights as soon as the provider answers. Cached responses
+// produce no updates. onUpdate is called from the caller's goroutine.
+func (s *SearchService) SearchStream(ctx context.Context, req models.SearchRequest, onUpdate func(models.ProviderUpdate)) (*models.SearchResponse, error) {
+	return s.search(ctx, req, onUpdate)
+}
+
+// search runs the search pipeline, streaming provider results to onUpdate when set
+func (s *SearchService) search(ctx context.Context, req models.SearchRequest, onUpdate func(models.ProviderUpdate)) (*models.SearchResponse, error) {
 	startTime := time.Now()
 
 	// Step 1: Validate request
@@ -106,205 +217,243 @@ func (s *SearchService) Search(ctx context.Context, req models.SearchRequest) (*
 		return nil, err
 	}
 
-	// Step 2: Check cache
-	cacheKey := s.cache.GenerateKey(req)
-	if cached, ok := s.cache.Get(cacheKey); ok {
-		log.Printf("Cache hit for key: %s", cacheKey)
-		response := cached.(*models.SearchResponse)
-		// Mark as cache hit
-		response.Metadata.CacheHit = true
-		return response, nil
-	}
-
-	log.Printf("Cache miss for key: %s", cacheKey)
+	s.recordSearch(req)
 
-	// Step 3: Aggregate from providers
-	aggregated, err := s.aggregator.SearchAll(ctx, req)
+	// Steps 2-3: Get provider results from the cache or the providers
+	results, err := s.aggregate(ctx, req, req.Filters, models.LegOutbound, onUpdate)
 	if err != nil {
 		// Return partial results if we have any
-		if aggregated != nil && len(aggregated.Flights) > 0 {
-			log.Printf("Partial results: got %d flights with errors", len(aggregated.Flights))
+		if results != nil && len(results.Flights) > 0 {
+			log.Printf("Partial results: got %d flights with errors", len(results.Flights))
 		} else {
 			return nil, err
 		}
 	}
 
-	flights := aggregated.Flights
+	// Steps 4-6: Filter, score and sort
+	flights, bestValueFlight := s.processFlights(results.Flights, req.Filters, req.SortBy, req.SortOrder, "flights")
+	flightMetaData := newSearchMetadata(results, len(flights), time.Since(startTime))
+
+	// Step 6.5: Search for return flights if return date is provided
+	var returnFlights []models.Flight
+	var bestValueReturnFlight *models.Flight
+	var returnMetadata *models.SearchMetadata
+	if req.ReturnDate != nil && *req.ReturnDate != "" {
+		log.Printf("Searching for return flights on %s", *req.ReturnDate)
+		returnStartTime := time.Now()
+
+		returnReq := newReturnRequest(req)
+		returnResults, err := s.aggregate(ctx, returnReq, returnReq.Filters, models.LegReturn, onUpdate)
+		if err != nil {
+			log.Printf("Error searching return flights: %v", err)
+			if returnResults != nil && len(returnResults.Flights) > 0 {
+				log.Printf("Partial return results: got %d flights with errors", len(returnResults.Flights))
+			}
+		}
+
+		if returnResults != nil {
+			returnFlights, bestValueReturnFlight = s.processFlights(returnResults.Flights, req.ReturnFilters, req.ReturnSortBy, req.ReturnSortOrder, "return flights")
 
-	// Step 4: Apply filters if provided
-	if req.Filters != nil {
-		log.Printf("Applying filters to %d flights", len(flights))
-		flights = s.filter.Apply(flights, *req.Filters)
-		log.Printf("After filtering: %d flights remaining", len(flights))
+			// Build return metadata
+			metadata := newSearchMetadata(returnResults, len(returnFlights), time.Since(returnStartTime))
+			returnMetadata = &metadata
+		}
 	}
 
-	// Step 5: Calculate scores and identify best value flight
+	// Build response
+	return &models.SearchResponse{
+		SearchCriteria:        newSearchCriteria(req),
+		Metadata:              flightMetaData,
+		Flights:               flights,
+		BestValueFlight:       bestValueFlight,
+		ReturnFlights:         returnFlights,
+		BestValueReturnFlight: bestValueReturnFlight,
+		ReturnMetadata:        returnMetadata,
+	}, nil
+}
+
+// legResults holds one leg's unfiltered provider results
+type legResults struct {
+	*aggregator.AggregatedResults
+	coalesced bool // shared with a concurrent identical search
+}
+
+// aggregate returns the unfiltered provider results for one leg of the trip
+// Providers with cached results are not queried again (see Aggregator.SetResultCache).
+// Concurrent identical searches share one fan-out. When onUpdate is set, each
+// provider's result is streamed with filters applied, so the fan-out is not shared.
+func (s *SearchService) aggregate(ctx context.Context, req models.SearchRequest, filters *models.FilterOptions, leg string, onUpdate func(models.ProviderUpdate)) (*legResults, error) {
+	if onUpdate == nil {
+		return s.searchCoalesced(ctx, req)
+	}
+
+	aggregated, err := s.aggregator.SearchAllStream(ctx, req, func(result aggregator.ProviderResult) {
+		update := models.ProviderUpdate{
+			Leg:        leg,
+			Provider:   result.Provider,
+			Flights:    []models.Flight{},
+			DurationMs: int(result.Duration.Milliseconds()),
+			CacheHit:   result.CacheHit,
+			Stale:      result.Stale,
+		}
+
+		if result.Error != nil {
+			update.Error = aggregator.ErrorMessage(result.Error)
+		} else {
+			update.Flights = result.Flights
+			if filters != nil {
+				update.Flights = s.filter.Apply(result.Flights, *filters)
+			}
+		}
+
+		onUpdate(update)
+	})
+	if aggregated == nil {
+		return nil, err
+	}
+	return &legResults{AggregatedResults: aggregated}, err
+}
+
+// newSearchCriteria echoes the search parameters used for the query
+func newSearchCriteria(req models.SearchRequest) models.SearchCriteria {
+	return models.SearchCriteria{
+		Origin:        req.Origin,
+		Destination:   req.Destination,
+		DepartureDate: req.DepartureDate,
+		ReturnDate:    req.ReturnDate,
+		Passengers:    req.Passengers,
+		CabinClass:    req.CabinClass,
+	}
+}
+
+// newReturnRequest creates a return flight search request (swap origin/destination)
+func newReturnRequest(req models.SearchRequest) models.SearchRequest {
+	return models.SearchRequest{
+		Origin:        req.Destination,
+		Destination:   req.Origin,
+		DepartureDate: *req.ReturnDate,
+		Passengers:    req.Passengers,
+		CabinClass:    req.CabinClass,
+		Filters:       req.ReturnFilters,
+		SortBy:        req.ReturnSortBy,
+		SortOrder:     req.ReturnSortOrder,
+	}
+}
+
+// processFlights applies filters, identifies the best value flight and applies custom sorting
+// label describes the flights in log messages (e.g. "flights", "return flights")
+func (s *SearchService) processFlights(flights []models.Flight, filters *models.FilterOptions, sortBy, sortOrder, label string) ([]models.Flight, *models.Flight) {
+	// Apply filters if provided
+	if filters != nil {
+		log.Printf("Applying filters to %d %s", len(flights), label)
+		flights = s.filter.Apply(flights, *filters)
+		log.Printf("After filtering: %d %s remaining", len(flights), label)
+	}
+
+	// Calculate scores and identify best value flight
 	var bestValueFlight *models.Flight
 	if len(flights) > 0 {
-		log.Printf("Scoring %d flights", len(flights))
+		log.Printf("Scoring %d %s", len(flights), label)
 		scoredFlights := s.scorer.ScoreFlights(flights)
 		// Extract the best value flight (highest score)
 		if len(scoredFlights) > 0 {
 			bestValueFlight = &scoredFlights[0].Flight
-			log.Printf("Best value flight: %s with score %.2f", bestValueFlight.FlightNumber, scoredFlights[0].Score)
+			log.Printf("Best value among %s: %s with score %.2f", label, bestValueFlight.FlightNumber, scoredFlights[0].Score)
 		}
 		// Keep flights in original order (don't reorder)
 	}
 
-	// Step 6: Apply custom sorting if requested
-	if req.SortBy != "" {
-		log.Printf("Sorting flights by %s (%s)", req.SortBy, req.SortOrder)
-		flights = s.sorter.Sort(flights, req.SortBy, req.SortOrder)
+	// Apply custom sorting if requested
+	if sortBy != "" {
+		log.Printf("Sorting %s by %s (%s)", label, sortBy, sortOrder)
+		flights = s.sorter.Sort(flights, sortBy, sortOrder)
 	}
 
-	// Calculate providers succeeded and failed
-	providersSucceeded := 0
-	for _, count := range aggregated.ProviderResults {
-		if count > 0 {
-			providersSucceeded++
+	return flights, bestValueFlight
+}
+
+// newSearchMetadata builds search metadata from a leg's provider results
+func newSearchMetadata(aggregated *legResults, totalResults int, searchTime time.Duration) models.SearchMetadata {
+	// Providers that answered with no flights succeeded; only errors count as failures
+	providersSucceeded := len(aggregated.ProviderResults)
+	providersFailed := len(aggregated.ProviderErrors)
+	providersPending := len(aggregated.ProviderPending)
+
+	// The leg is a cache hit when every provider that answered was served from the cache
+	cacheHit := len(aggregated.ProviderCache) > 0 && providersPending == 0
+	stale := false
+	for _, status := range aggregated.ProviderCache {
+		switch status {
+		case aggregator.CacheStatusMiss:
+			cacheHit = false
+		case aggregator.CacheStatusStale:
+			stale = true
 		}
 	}
-	providersFailed := len(aggregated.ProviderErrors)
 
-	flightMetaData := models.SearchMetadata{
-		TotalResults:       len(flights),
-		ProvidersQueried:   len(aggregated.ProviderResults),
+	metadata := models.SearchMetadata{
+		TotalResults:       totalResults,
+		ProvidersQueried:   providersSucceeded + providersFailed + providersPending,
 		ProvidersSucceeded: providersSucceeded,
 		ProvidersFailed:    providersFailed,
-		SearchTimeMs:       int(time.Since(startTime).Milliseconds()),
-		CacheHit:           false,
+		ProvidersSkipped:   len(aggregated.ProviderSkipped),
+		ProvidersPending:   providersPending,
+		SearchTimeMs:       int(searchTime.Milliseconds()),
+		CacheHit:           cacheHit,
+		Stale:              stale,
+		Coalesced:          aggregated.coalesced,
+		DuplicatesMerged:   aggregated.DuplicatesMerged,
 		ProviderResults:    aggregated.ProviderResults,
 		ProviderErrors:     aggregated.ProviderErrors,
+		ProviderSkipped:    aggregated.ProviderSkipped,
+		ProviderPending:    aggregated.ProviderPending,
+		ProviderCache:      aggregated.ProviderCache,
 	}
 
-	// Step 6.5: Search for return flights if return date is provided
-	var returnFlights []models.Flight
-	var bestValueReturnFlight *models.Flight
-	var returnMetadata *models.SearchMetadata
-	if req.ReturnDate != nil && *req.ReturnDate != "" {
-		log.Printf("Searching for return flights on %s", *req.ReturnDate)
-		returnStartTime := time.Now()
-
-		// Create a return flight search request (swap origin/destination)
-		returnReq := models.SearchRequest{
-			Origin:        req.Destination,
-			Destination:   req.Origin,
-			DepartureDate: *req.ReturnDate,
-			Passengers:    req.Passengers,
-			CabinClass:    req.CabinClass,
-			Filters:       req.ReturnFilters,
-			SortBy:        req.ReturnSortBy,
-			SortOrder:     req.ReturnSortOrder,
-		}
-
-		// Check cache for return flights
-		returnCacheKey := s.cache.GenerateKey(returnReq)
-		if cached, ok := s.cache.Get(returnCacheKey); ok {
-			log.Printf("Cache hit for return flights key: %s", returnCacheKey)
-			cachedResponse := cached.(*models.SearchResponse)
-			returnFlights = cachedResponse.Flights
-			bestValueReturnFlight = cachedResponse.BestValueFlight
-			returnMetadata = cachedResponse.ReturnMetadata
-			if returnMetadata != nil {
-				returnMetadata.CacheHit = true
-				returnMetadata.SearchTimeMs = int(time.Since(returnStartTime).Milliseconds())
-			}
-			if bestValueReturnFlight != nil {
-				log.Printf("Best value return flight from cache: %s", bestValueReturnFlight.FlightNumber)
-			}
-		} else {
-			log.Printf("Cache miss for return flights key: %s", returnCacheKey)
-
-			// Aggregate from providers for return flights
-			returnAggregated, err := s.aggregator.SearchAll(ctx, returnReq)
-			if err != nil {
-				log.Printf("Error searching return flights: %v", err)
-				if returnAggregated != nil && len(returnAggregated.Flights) > 0 {
-					log.Printf("Partial return results: got %d flights with errors", len(returnAggregated.Flights))
-				}
-			}
-
-			if returnAggregated != nil {
-				returnFlights = returnAggregated.Flights
-
-				// Apply filters if provided
-				if req.ReturnFilters != nil {
-					log.Printf("Applying filters to %d return flights", len(returnFlights))
-					returnFlights = s.filter.Apply(returnFlights, *req.ReturnFilters)
-					log.Printf("After filtering: %d return flights remaining", len(returnFlights))
-				}
-
-				// Calculate scores and identify best value return flight
-				if len(returnFlights) > 0 {
-					log.Printf("Scoring %d return flights", len(returnFlights))
-					scoredReturnFlights := s.scorer.ScoreFlights(returnFlights)
-					// Extract the best value return flight (highest score)
-					if len(scoredReturnFlights) > 0 {
-						bestValueReturnFlight = &scoredReturnFlights[0].Flight
-						log.Printf("Best value return flight: %s with score %.2f", bestValueReturnFlight.FlightNumber, scoredReturnFlights[0].Score)
-					}
-					// Keep return flights in original order (don't reorder)
-				}
-
-				// Apply custom sorting if requested
-				if req.ReturnSortBy != "" {
-					log.Printf("Sorting return flights by %s (%s)", req.ReturnSortBy, req.ReturnSortOrder)
-					returnFlights = s.sorter.Sort(returnFlights, req.ReturnSortBy, req.ReturnSortOrder)
-				}
-
-				// Build return metadata
-				returnProvidersSucceeded := 0
-				for _, count := range returnAggregated.ProviderResults {
-					if count > 0 {
-						returnProvidersSucceeded++
-					}
-				}
-				returnProvidersFailed := len(returnAggregated.ProviderErrors)
-
-				returnMetadata = &models.SearchMetadata{
-					TotalResults:       len(returnFlights),
-					ProvidersQueried:   len(returnAggregated.ProviderResults),
-					ProvidersSucceeded: returnProvidersSucceeded,
-					ProvidersFailed:    returnProvidersFailed,
-					SearchTimeMs:       int(time.Since(returnStartTime).Milliseconds()),
-					CacheHit:           false,
-					ProviderResults:    returnAggregated.ProviderResults,
-					ProviderErrors:     returnAggregated.ProviderErrors,
-				}
-			}
-		}
+	if !aggregated.FetchedAt.IsZero() {
+		cachedAt := aggregated.FetchedAt.UTC()
+		metadata.CachedAt = &cachedAt
+		metadata.AgeSeconds = int(time.Since(cachedAt).Seconds())
 	}
-
-	// Build response
-	response := &models.SearchResponse{
-		SearchCriteria: models.SearchCriteria{
-			Origin:        req.Origin,
-			Destination:   req.Destination,
-			DepartureDate: req.DepartureDate,
-			ReturnDate:    req.ReturnDate,
-			Passengers:    req.Passengers,
-			CabinClass:    req.CabinClass,
-		},
-		Metadata:              flightMetaData,
-		Flights:               flights,
-		BestValueFlight:       bestValueFlight,
-		ReturnFlights:         returnFlights,
-		BestValueReturnFlight: bestValueReturnFlight,
-		ReturnMetadata:        returnMetadata,
+	if !aggregated.ExpiresAt.IsZero() {
+		expiresAt := aggregated.ExpiresAt.UTC()
+		metadata.ExpiresAt = &expiresAt
 	}
 
-	// Cache response
-	s.cache.Set(cacheKey, response)
-	log.Printf("Cached response for key: %s", cacheKey)
+	return metadata
+}
+
+// Warmer returns the cache warmer
+func (s *SearchService) Warmer() *CacheWarmer {
+	return s.warmer
+}
 
-	return response, nil
+// InvalidateCache removes cached provider results matching the selector
+func (s *SearchService) InvalidateCache(sel cache.Selector) int {
+	deleted := s.cache.Invalidate(sel)
+	log.Printf("Invalidated %d cache entries (provider=%q origin=%q destination=%q date=%q)",
+		deleted, sel.Provider, sel.Origin, sel.Destination, sel.Date)
+	return deleted
 }
 
-// GetProviders returns list of available providers
-func (s *SearchService) GetProviders() []string {
-	providerNames := make([]string, len(s.providers))
+// CacheStats returns the cache statistics
+func (s *SearchService) CacheStats() cache.Stats {
+	return s.cache.Stats()
+}
+
+// GetProviders returns the available providers with their circuit breaker state and metrics
+func (s *SearchService) GetProviders() []models.ProviderStatus {
+	statuses := make([]models.ProviderStatus, len(s.providers))
 	for i, p := range s.providers {
-		providerNames[i] = p.Name()
+		metrics := s.aggregator.Metrics(p.Name())
+		statuses[i] = models.ProviderStatus{
+			Name:         p.Name(),
+			Carriers:     p.Carriers(),
+			CircuitState: string(s.aggregator.CircuitState(p.Name())),
+			P95LatencyMs: int(metrics.P95Latency.Milliseconds()),
+			Hedges:       metrics.Hedges,
+			HedgeWins:    metrics.HedgeWins,
+		}
 	}
-	return providerNames
+	return statuses
 }
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flight-aggregator/internal/aggregator"
+	"flight-aggregator/internal/models"
+	"flight-aggregator/internal/validator"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrSessionNotFound is returned when a search session does not exist or has expired
+var ErrSessionNotFound = errors.New("search session not found or expired")
+
+// defaultSessionTTL is used when no session idle timeout is configured
+const defaultSessionTTL = 5 * time.Minute
+
+// sessionResults holds a live search session's unfiltered provider results
+type sessionResults struct {
+	Request          models.SearchRequest          `json:"request"`
+	Aggregated       *aggregator.AggregatedResults `json:"aggregated"`
+	ReturnAggregated *aggregator.AggregatedResults `json:"return_aggregated,omitempty"` // nil for one-way searches
+}
+
+// sessionStore keeps live search sessions in memory, apart from the result cache so
+// that search traffic cannot evict a session in use. Sessions expire after being idle
+// for ttl; sessions of closed connections are removed by CloseSession.
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+	ttl      time.Duration
+}
+
+// session is a stored live search session
+type session struct {
+	results  sessionResults
+	lastUsed time.Time
+}
+
+// newSessionStore creates a session store whose sessions expire after being idle for ttl
+// (defaults to 5 minutes)
+func newSessionStore(ttl time.Duration) *sessionStore {
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+
+	s := &sessionStore{
+		sessions: make(map[string]*session),
+		ttl:      ttl,
+	}
+
+	// Start background cleanup goroutine
+	go s.cleanupExpired()
+
+	return s
+}
+
+// put stores a session's results
+func (s *sessionStore) put(id string, results sessionResults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[id] = &session{results: results, lastUsed: time.Now()}
+}
+
+// get returns a session's results and marks the session as used
+// Returns false if the session does not exist or has been idle for longer than the TTL
+func (s *sessionStore) get(id string) (sessionResults, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.sessions[id]
+	if !exists {
+		return sessionResults{}, false
+	}
+
+	if time.Since(stored.lastUsed) > s.ttl {
+		delete(s.sessions, id)
+		return sessionResults{}, false
+	}
+
+	stored.lastUsed = time.Now()
+	return stored.results, true
+}
+
+// delete removes a session
+func (s *sessionStore) delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+}
+
+// cleanupExpired periodically removes idle sessions
+func (s *sessionStore) cleanupExpired() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		for id, stored := range s.sessions {
+			if time.Since(stored.lastUsed) > s.ttl {
+				delete(s.sessions, id)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// OpenSession runs a search for a live session, streaming each provider's results to
+// onUpdate, and keeps the unfiltered results in memory so the session can be refined
+// with RefineSession without re-querying providers. Providers are routed by route only,
+// not by the airline filter, so refinements may change airlines.
+// Returns the session ID and the filtered, sorted response.
+func (s *SearchService) OpenSession(ctx context.Context, req models.SearchRequest, onUpdate func(models.ProviderUpdate)) (string, *models.SearchResponse, error) {
+	startTime := time.Now()
+
+	if err := s.validator.ValidateSearchRequest(req); err != nil {
+		return "", nil, err
+	}
+
+	results := &sessionResults{Request: req}
+
+	aggregated, err := s.aggregate(ctx, withoutAirlineRouting(req), req.Filters, models.LegOutbound, onUpdate)
+	if err != nil && (aggregated == nil || len(aggregated.Flights) == 0) {
+		return "", nil, err
+	}
+	results.Aggregated = aggregated.AggregatedResults
+
+	if req.ReturnDate != nil && *req.ReturnDate != "" {
+		returnReq := newReturnRequest(req)
+		returnAggregated, err := s.aggregate(ctx, withoutAirlineRouting(returnReq), returnReq.Filters, models.LegReturn, onUpdate)
+		if err != nil {
+			log.Printf("Error searching return flights for session: %v", err)
+		}
+		if returnAggregated != nil {
+			results.ReturnAggregated = returnAggregated.AggregatedResults
+		}
+	}
+
+	sessionID, err := newSessionID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	s.sessions.put(sessionID, *results)
+	log.Printf("Opened search session %s", sessionID)
+
+	return sessionID, s.sessionResponse(results, false, time.Since(startTime)), nil
+}
+
+// RefineSession applies new filters and sort options to a session's results
+func (s *SearchService) RefineSession(sessionID string, refinement models.SessionRefinement) (*models.SearchResponse, error) {
+	startTime := time.Now()
+
+	// Validate filters if provided, reporting every invalid filter of both legs
+	var errs validator.ValidationErrors
+	if refinement.Filters != nil {
+		if list, ok := validator.AsValidationErrors(validator.WithFieldPrefix(s.validator.ValidateFilters(*refinement.Filters), "filters")); ok {
+			errs = append(errs, list...)
+		}
+	}
+	if refinement.ReturnFilters != nil {
+		if list, ok := validator.AsValidationErrors(validator.WithFieldPrefix(s.validator.ValidateFilters(*refinement.ReturnFilters), "returnFilters")); ok {
+			errs = append(errs, list...)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	refined, ok := s.sessions.get(sessionID)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	// Refinements replace the previous filters and sort options
+	refined.Request.Filters = refinement.Filters
+	refined.Request.SortBy = refinement.SortBy
+	refined.Request.SortOrder = refinement.SortOrder
+	refined.Request.ReturnFilters = refinement.ReturnFilters
+	refined.Request.ReturnSortBy = refinement.ReturnSortBy
+	refined.Request.ReturnSortOrder = refinement.ReturnSortOrder
+
+	s.sessions.put(sessionID, refined)
+	log.Printf("Refined search session %s", sessionID)
+
+	return s.sessionResponse(&refined, true, time.Since(startTime)), nil
+}
+
+// CloseSession discards a session's results
+func (s *SearchService) CloseSession(sessionID string) {
+	s.sessions.delete(sessionID)
+	log.Printf("Closed search session %s", sessionID)
+}
+
+// sessionResponse filters, scores and sorts a session's results into a search response
+// Refined responses always report a cache hit since providers are not re-queried
+func (s *SearchService) sessionResponse(results *sessionResults, refined bool, searchTime time.Duration) *models.SearchResponse {
+	req := results.Request
+
+	flights, bestValueFlight := s.processFlights(results.Aggregated.Flights, req.Filters, req.SortBy, req.SortOrder, "flights")
+	metadata := newSearchMetadata(&legResults{AggregatedResults: results.Aggregated}, len(flights), searchTime)
+	metadata.CacheHit = metadata.CacheHit || refined
+
+	response := &models.SearchResponse{
+		SearchCriteria:  newSearchCriteria(req),
+		Metadata:        metadata,
+		Flights:         flights,
+		BestValueFlight: bestValueFlight,
+	}
+
+	if results.ReturnAggregated != nil {
+		returnFlights, bestValueReturnFlight := s.processFlights(results.ReturnAggregated.Flights, req.ReturnFilters, req.ReturnSortBy, req.ReturnSortOrder, "return flights")
+		returnMetadata := newSearchMetadata(&legResults{AggregatedResults: results.ReturnAggregated}, len(returnFlights), searchTime)
+		returnMetadata.CacheHit = returnMetadata.CacheHit || refined
+
+		response.ReturnFlights = returnFlights
+		response.BestValueReturnFlight = bestValueReturnFlight
+		response.ReturnMetadata = &returnMetadata
+	}
+
+	return response
+}
+
+// withoutAirlineRouting returns a copy of the request whose airline filter does not restrict
+// which providers are queried; airline filters are still applied to the results
+func withoutAirlineRouting(req models.SearchRequest) models.SearchRequest {
+	if req.Filters != nil && len(req.Filters.Airlines) > 0 {
+		filters := *req.Filters
+		filters.Airlines = nil
+		req.Filters = &filters
+	}
+	return req
+}
+
+// newSessionID generates a random session identifier
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"flight-aggregator/internal/models"
+	"flight-aggregator/internal/providers"
+	"flight-aggregator/pkg/circuitbreaker"
+	"flight-aggregator/pkg/config"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrWarmerRunning is returned when starting a cache warmer that is already running
+var ErrWarmerRunning = errors.New("cache warmer is already running")
+
+// ErrWarmerStopped is returned when stopping a cache warmer that is not running
+var ErrWarmerStopped = errors.New("cache warmer is not running")
+
+// popularWindow is how far back searches count towards popular routes, counted in
+// hourly buckets
+const (
+	popularWindow  = 24 * time.Hour
+	popularBucket  = time.Hour
+	popularBuckets = int(popularWindow / popularBucket)
+)
+
+// WarmQuery identifies the provider query warmed for a route
+type WarmQuery struct {
+	Origin      string `json:"origin"`
+	Destination string `json:"destination"`
+	Passengers  int    `json:"passengers"`
+	CabinClass  string `json:"cabin_class"`
+}
+
+// WarmerStatus reports the cache warmer's state and last run
+type WarmerStatus struct {
+	Running       bool        `json:"running"`
+	Interval      string      `json:"interval"`
+	DaysAhead     int         `json:"days_ahead"`
+	Source        string      `json:"source"` // "config" or "popular"
+	Queries       []WarmQuery `json:"queries"`
+	LastRunStart  *time.Time  `json:"last_run_start,omitempty"`
+	LastRunEnd    *time.Time  `json:"last_run_end,omitempty"`
+	LastSearches  int         `json:"last_searches"` // provider queries made
+	LastFresh     int         `json:"last_fresh"`    // provider queries skipped, cached results fresh past the next run
+	LastSkipped   int         `json:"last_skipped"`  // provider queries skipped, circuit open
+	LastErrors    int         `json:"last_errors"`
+	TotalSearches int64       `json:"total_searches"`
+}
+
+// warmRun counts the outcome of the provider queries of a warm run
+type warmRun struct {
+	mu       sync.Mutex
+	searches int
+	fresh    int
+	skipped  int
+	failed   int
+}
+
+// CacheWarmer periodically queries providers for popular routes for the next days so
+// that user searches for them are cache hits. Each provider is warmed on its own
+// goroutine, paced by its own rate limit; cached results that stay fresh past the next
+// run are left alone and providers with open circuits are skipped.
+type CacheWarmer struct {
+	service   *SearchService
+	interval  time.Duration
+	daysAhead int
+	startDate string // YYYY-MM-DD; empty means today
+	queries   []WarmQuery
+	topRoutes int
+	limiters  map[string]*rate.Limiter // provider name -> warm query pacing
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // nil when stopped
+	done   chan struct{}
+	status WarmerStatus
+}
+
+// newCacheWarmer creates a cache warmer from config
+// Without configured routes, the most searched routes of the last 24 hours are warmed
+func newCacheWarmer(s *SearchService, cfg config.CacheWarmerConfig) *CacheWarmer {
+	w := &CacheWarmer{
+		service:   s,
+		interval:  cfg.GetInterval(),
+		daysAhead: cfg.DaysAhead,
+		startDate: cfg.StartDate,
+		topRoutes: cfg.TopRoutes,
+	}
+
+	if w.interval <= 0 {
+		w.interval = 5 * time.Minute
+	}
+	if w.daysAhead <= 0 {
+		w.daysAhead = 7
+	}
+	if w.topRoutes <= 0 {
+		w.topRoutes = 5
+	}
+
+	searchesPerMinute := cfg.MaxSearchesPerMinute
+	if searchesPerMinute <= 0 {
+		searchesPerMinute = 30
+	}
+	w.limiters = make(map[string]*rate.Limiter, len(s.providers))
+	for _, provider := range s.providers {
+		w.limiters[provider.Name()] = rate.NewLimiter(rate.Limit(float64(searchesPerMinute)/60.0), 1)
+	}
+
+	passengers := cfg.Passengers
+	if passengers <= 0 {
+		passengers = 1
+	}
+	cabinClass := cfg.CabinClass
+	if cabinClass == "" {
+		cabinClass = "economy"
+	}
+	for _, route := range cfg.Routes {
+		origin, destination, ok := strings.Cut(strings.ToUpper(strings.TrimSpace(route)), "-")
+		if !ok {
+			log.Printf("Warning: ignoring invalid cache warmer route %q (expected ORIGIN-DESTINATION)", route)
+			continue
+		}
+		w.queries = append(w.queries, WarmQuery{Origin: origin, Destination: destination, Passengers: passengers, CabinClass: cabinClass})
+	}
+
+	return w
+}
+
+// Start runs the warmer in the background until Stop is called
+func (w *CacheWarmer) Start() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cancel != nil {
+		return ErrWarmerRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+	w.status.Running = true
+
+	go w.run(ctx, w.done)
+	log.Printf("Cache warmer started (interval: %v, days ahead: %d)", w.interval, w.daysAhead)
+	return nil
+}
+
+// Stop stops the warmer and waits for the current search to finish
+func (w *CacheWarmer) Stop() error {
+	w.mu.Lock()
+	if w.cancel == nil {
+		w.mu.Unlock()
+		return ErrWarmerStopped
+	}
+	w.cancel()
+	w.cancel = nil
+	done := w.done
+	w.status.Running = false
+	w.mu.Unlock()
+
+	<-done
+	log.Printf("Cache warmer stopped")
+	return nil
+}
+
+// Status returns the warmer's state and the queries it would warm next
+func (w *CacheWarmer) Status() WarmerStatus {
+	queries, source := w.warmQueries()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := w.status
+	status.Interval = w.interval.String()
+	status.DaysAhead = w.daysAhead
+	status.Source = source
+	status.Queries = queries
+	return status
+}
+
+// run warms the cache immediately and then every interval
+func (w *CacheWarmer) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.warm(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// warm refreshes every provider's cached results for each warm query and day
+func (w *CacheWarmer) warm(ctx context.Context) {
+	queries, source := w.warmQueries()
+	start := time.Now()
+
+	w.mu.Lock()
+	w.status.LastRunStart = &start
+	w.status.LastRunEnd = nil
+	w.status.LastSearches, w.status.LastFresh, w.status.LastSkipped, w.status.LastErrors = 0, 0, 0, 0
+	w.mu.Unlock()
+
+	firstDay := time.Now()
+	if w.startDate != "" {
+		if day, err := time.Parse("2006-01-02", w.startDate); err == nil {
+			firstDay = day
+		}
+	}
+
+	// Group requests by the providers a user search would query
+	requests := make(map[string][]models.SearchRequest)
+	for _, query := range queries {
+		for day := 0; day < w.daysAhead; day++ {
+			req := models.SearchRequest{
+				Origin:        query.Origin,
+				Destination:   query.Destination,
+				DepartureDate: firstDay.AddDate(0, 0, day).Format("2006-01-02"),
+				Passengers:    query.Passengers,
+				CabinClass:    query.CabinClass,
+			}
+			for _, provider := range w.service.aggregator.SelectProviders(req) {
+				requests[provider.Name()] = append(requests[provider.Name()], req)
+			}
+		}
+	}
+
+	run := &warmRun{}
+	var wg sync.WaitGroup
+	for _, provider := range w.service.providers {
+		if len(requests[provider.Name()]) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(p providers.Provider) {
+			defer wg.Done()
+			w.warmProvider(ctx, p, requests[p.Name()], run)
+		}(provider)
+	}
+	wg.Wait()
+
+	w.finishRun(run)
+	if ctx.Err() != nil {
+		return
+	}
+	log.Printf("Cache warmer run finished in %v: %d %s queries, %d provider searches, %d fresh, %d skipped, %d with errors",
+		time.Since(start), len(queries), source, run.searches, run.fresh, run.skipped, run.failed)
+}
+
+// warmProvider refreshes one provider's cached results for the requests, paced by the
+// provider's rate limit
+func (w *CacheWarmer) warmProvider(ctx context.Context, provider providers.Provider, requests []models.SearchRequest, run *warmRun) {
+	agg := w.service.aggregator
+	limiter := w.limiters[provider.Name()]
+
+	for _, req := range requests {
+		// Open circuits would fail fast; don't spend the provider's rate on them
+		if agg.CircuitState(provider.Name()) == circuitbreaker.StateOpen {
+			run.add(&run.skipped)
+			continue
+		}
+
+		// Results still fresh when the next run starts are refreshed by that run
+		if !agg.NeedsRefresh(provider, req, time.Now().Add(w.interval)) {
+			run.add(&run.fresh)
+			continue
+		}
+
+		if err := limiter.Wait(ctx); err != nil {
+			return
+		}
+
+		run.add(&run.searches)
+		if err := agg.RefreshProvider(ctx, provider, req); err != nil && ctx.Err() == nil {
+			run.add(&run.failed)
+		}
+	}
+}
+
+// add increments one of the run's counters
+func (r *warmRun) add(counter *int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*counter++
+}
+
+// finishRun records the outcome of a warm run
+func (w *CacheWarmer) finishRun(run *warmRun) {
+	end := time.Now()
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.status.LastRunEnd = &end
+	w.status.LastSearches = run.searches
+	w.status.LastFresh = run.fresh
+	w.status.LastSkipped = run.skipped
+	w.status.LastErrors = run.failed
+	w.status.TotalSearches += int64(run.searches)
+}
+
+// warmQueries returns the configured queries, or the most searched recent ones
+func (w *CacheWarmer) warmQueries() ([]WarmQuery, string) {
+	if len(w.queries) > 0 {
+		return w.queries, "config"
+	}
+	return w.service.popularQueries(w.topRoutes), "popular"
+}
+
+// queryUsage counts user searches for a warm query per hour, over popularWindow
+type queryUsage struct {
+	query   WarmQuery
+	buckets [popularBuckets]struct {
+		hour  int64 // hours since the Unix epoch; the bucket is reused once it falls out of the window
+		count int
+	}
+}
+
+// record counts a search at now
+func (u *queryUsage) record(now time.Time) {
+	hour := now.Unix() / int64(popularBucket/time.Second)
+	bucket := &u.buckets[hour%int64(popularBuckets)]
+	if bucket.hour != hour {
+		bucket.hour, bucket.count = hour, 0
+	}
+	bucket.count++
+}
+
+// recent returns the number of searches within popularWindow of now
+func (u *queryUsage) recent(now time.Time) int {
+	hour := now.Unix() / int64(popularBucket/time.Second)
+	total := 0
+	for _, bucket := range u.buckets {
+		if hour-bucket.hour < int64(popularBuckets) {
+			total += bucket.count
+		}
+	}
+	return total
+}
+
+// searchLog records user searches so the warmer can find popular routes
+type searchLog struct {
+	mu      sync.Mutex
+	queries map[string]*queryUsage
+}
+
+// recordSearch counts a user search towards popular routes
+func (s *SearchService) recordSearch(req models.SearchRequest) {
+	query := WarmQuery{
+		Origin:      strings.ToUpper(req.Origin),
+		Destination: strings.ToUpper(req.Destination),
+		Passengers:  req.Passengers,
+		CabinClass:  strings.ToLower(req.CabinClass),
+	}
+	key := fmt.Sprintf("%s:%d:%s", providers.RouteKey(query.Origin, query.Destination), query.Passengers, query.CabinClass)
+
+	s.searchLog.mu.Lock()
+	defer s.searchLog.mu.Unlock()
+
+	if s.searchLog.queries == nil {
+		s.searchLog.queries = make(map[string]*queryUsage)
+	}
+	usage, ok := s.searchLog.queries[key]
+	if !ok {
+		usage = &queryUsage{query: query}
+		s.searchLog.queries[key] = usage
+	}
+	usage.record(time.Now())
+}
+
+// popularQueries returns up to n of the most searched queries of the last 24 hours
+func (s *SearchService) popularQueries(n int) []WarmQuery {
+	s.searchLog.mu.Lock()
+	defer s.searchLog.mu.Unlock()
+
+	now := time.Now()
+	type ranked struct {
+		query WarmQuery
+		count int
+	}
+	recent := make([]ranked, 0, len(s.searchLog.queries))
+	for key, usage := range s.searchLog.queries {
+		count := usage.recent(now)
+		if count == 0 {
+			delete(s.searchLog.queries, key)
+			continue
+		}
+		recent = append(recent, ranked{query: usage.query, count: count})
+	}
+
+	sort.Slice(recent, func(i, j int) bool {
+		return recent[i].count > recent[j].count
+	})
+
+	queries := make([]WarmQuery, 0, n)
+	for i := 0; i < len(recent) && i < n; i++ {
+		queries = append(queries, recent[i].query)
+	}
+	return queries
+}
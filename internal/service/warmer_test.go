@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryUsageCountsOnlyRecentSearches(t *testing.T) {
+	now := time.Date(2025, 12, 10, 12, 30, 0, 0, time.UTC)
+
+	var lastWeek, today queryUsage
+	for i := 0; i < 1000; i++ {
+		lastWeek.record(now.Add(-7 * 24 * time.Hour))
+	}
+	lastWeek.record(now)
+	for i := 0; i < 5; i++ {
+		today.record(now.Add(-time.Duration(i) * time.Hour))
+	}
+
+	if got := lastWeek.recent(now); got != 1 {
+		t.Errorf("route searched 1000 times last week and once today: recent = %d, want 1", got)
+	}
+	if got := today.recent(now); got != 5 {
+		t.Errorf("route searched 5 times today: recent = %d, want 5", got)
+	}
+	if got := today.recent(now.Add(popularWindow)); got != 0 {
+		t.Errorf("recent a window later = %d, want 0", got)
+	}
+}
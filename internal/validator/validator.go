@@ -1,15 +1,26 @@
 package validator
 
 import (
+	"errors"
 	"flight-aggregator/internal/models"
 	"fmt"
 	"strings"
 	"time"
 )
 
+// Validation error codes reported in ValidationError.Code
+const (
+	CodeRequired      = "required"       // the field is missing or empty
+	CodeInvalidFormat = "invalid_format" // the value cannot be parsed
+	CodeInvalidValue  = "invalid_value"  // the value is not one of the allowed values
+	CodeOutOfRange    = "out_of_range"   // the value is outside the allowed range
+)
+
 // ValidationError represents a validation error
+// Field is the JSON path of the offending request field, e.g. returnFilters.maxStops
 type ValidationError struct {
 	Field   string
+	Code    string
 	Message string
 }
 
@@ -17,6 +28,57 @@ func (e ValidationError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Field, e.Message)
 }
 
+// ValidationErrors holds every validation error of a request, one per problem
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// add appends err if it is a validation error or a list of them
+// Returns whether err was non-nil.
+func (e *ValidationErrors) add(err error) bool {
+	var list ValidationErrors
+	var single ValidationError
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &list):
+		*e = append(*e, list...)
+	case errors.As(err, &single):
+		*e = append(*e, single)
+	}
+	return true
+}
+
+// err returns the errors as an error, or nil if there are none
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// AsValidationErrors returns the validation errors in err, whether it holds a single
+// ValidationError or ValidationErrors
+func AsValidationErrors(err error) (ValidationErrors, bool) {
+	var list ValidationErrors
+	if errors.As(err, &list) {
+		return list, true
+	}
+
+	var single ValidationError
+	if errors.As(err, &single) {
+		return ValidationErrors{single}, true
+	}
+
+	return nil, false
+}
+
 // Validator handles data validation
 type Validator struct{}
 
@@ -26,54 +88,48 @@ func NewValidator() *Validator {
 }
 
 // ValidateSearchRequest validates a search request
+// Every invalid field is reported, as ValidationErrors.
 func (v *Validator) ValidateSearchRequest(req models.SearchRequest) error {
-	// Validate origin
-	if err := v.validateAirportCode(req.Origin, "Origin"); err != nil {
-		return err
-	}
+	var errs ValidationErrors
 
-	// Validate destination
-	if err := v.validateAirportCode(req.Destination, "Destination"); err != nil {
-		return err
-	}
+	// Validate origin and destination
+	originInvalid := errs.add(v.validateAirportCode(req.Origin, "origin"))
+	destinationInvalid := errs.add(v.validateAirportCode(req.Destination, "destination"))
 
 	// Origin and destination must be different
-	if strings.EqualFold(req.Origin, req.Destination) {
-		return ValidationError{
-			Field:   "Destination",
+	if !originInvalid && !destinationInvalid && strings.EqualFold(req.Origin, req.Destination) {
+		errs.add(ValidationError{
+			Field:   "destination",
+			Code:    CodeInvalidValue,
 			Message: "origin and destination must be different",
-		}
+		})
 	}
 
 	// Validate departure date
-	departureDate, err := v.validateDate(req.DepartureDate, "DepartureDate")
-	if err != nil {
-		return err
-	}
+	departureDate, err := v.validateDate(req.DepartureDate, "departureDate")
+	departureInvalid := errs.add(err)
 
 	// Validate return date if provided
 	if req.ReturnDate != nil && *req.ReturnDate != "" {
-		returnDate, err := v.validateDate(*req.ReturnDate, "ReturnDate")
-		if err != nil {
-			return err
-		}
+		returnDate, err := v.validateDate(*req.ReturnDate, "returnDate")
 
 		// Return date must be on or after departure date
-		if returnDate.Before(departureDate) {
-			return ValidationError{
-				Field:   "ReturnDate",
+		if !errs.add(err) && !departureInvalid && returnDate.Before(departureDate) {
+			errs.add(ValidationError{
+				Field:   "returnDate",
+				Code:    CodeOutOfRange,
 				Message: "return date must be on or after departure date",
-			}
+			})
 		}
 	}
 
 	// Validate passengers
 	if req.Passengers < 1 {
-		return ValidationError{Field: "Passengers", Message: "must have at least 1 passenger"}
+		errs.add(ValidationError{Field: "passengers", Code: CodeOutOfRange, Message: "must have at least 1 passenger"})
 	}
 
 	if req.Passengers > 9 {
-		return ValidationError{Field: "Passengers", Message: "maximum 9 passengers per search"}
+		errs.add(ValidationError{Field: "passengers", Code: CodeOutOfRange, Message: "maximum 9 passengers per search"})
 	}
 
 	// Validate cabin class
@@ -84,39 +140,55 @@ func (v *Validator) ValidateSearchRequest(req models.SearchRequest) error {
 		"first":    true,
 	}
 
-	if !validCabinClasses[strings.ToLower(req.CabinClass)] {
-		return ValidationError{
-			Field:   "CabinClass",
+	if req.CabinClass == "" {
+		errs.add(ValidationError{Field: "cabinClass", Code: CodeRequired, Message: "cabin class is required"})
+	} else if !validCabinClasses[strings.ToLower(req.CabinClass)] {
+		errs.add(ValidationError{
+			Field:   "cabinClass",
+			Code:    CodeInvalidValue,
 			Message: "cabin class must be economy, premium, business, or first",
-		}
+		})
 	}
 
 	// Validate filters if provided
 	if req.Filters != nil {
-		if err := v.ValidateFilters(*req.Filters); err != nil {
-			return err
-		}
+		errs.add(WithFieldPrefix(v.ValidateFilters(*req.Filters), "filters"))
 	}
 
 	// Validate return filters if provided
 	if req.ReturnFilters != nil {
-		if err := v.ValidateFilters(*req.ReturnFilters); err != nil {
-			return err
-		}
+		errs.add(WithFieldPrefix(v.ValidateFilters(*req.ReturnFilters), "returnFilters"))
 	}
 
-	return nil
+	return errs.err()
+}
+
+// WithFieldPrefix qualifies the fields of validation errors with their parent field,
+// e.g. maxStops in the return filters becomes returnFilters.maxStops
+func WithFieldPrefix(err error, prefix string) error {
+	list, ok := AsValidationErrors(err)
+	if !ok {
+		return err
+	}
+
+	prefixed := make(ValidationErrors, len(list))
+	for i, validationErr := range list {
+		validationErr.Field = prefix + "." + validationErr.Field
+		prefixed[i] = validationErr
+	}
+	return prefixed
 }
 
 // validateAirportCode validates airport code format (IATA 3-letter code)
 func (v *Validator) validateAirportCode(code, field string) error {
 	if code == "" {
-		return ValidationError{Field: field, Message: "airport code is required"}
+		return ValidationError{Field: field, Code: CodeRequired, Message: "airport code is required"}
 	}
 
 	if len(code) != 3 {
 		return ValidationError{
 			Field:   field,
+			Code:    CodeInvalidFormat,
 			Message: "airport code must be 3 characters (IATA code)",
 		}
 	}
@@ -126,6 +198,7 @@ func (v *Validator) validateAirportCode(code, field string) error {
 		if !((char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z')) {
 			return ValidationError{
 				Field:   field,
+				Code:    CodeInvalidFormat,
 				Message: "airport code must contain only letters",
 			}
 		}
@@ -135,75 +208,114 @@ func (v *Validator) validateAirportCode(code, field string) error {
 }
 
 // ValidateFilters validates filter options
+// Every invalid filter is reported, as ValidationErrors.
 func (v *Validator) ValidateFilters(filters models.FilterOptions) error {
+	var errs ValidationErrors
+
 	// Validate price range
-	if filters.MinPrice != nil && *filters.MinPrice < 0 {
-		return ValidationError{Field: "MinPrice", Message: "minimum price cannot be negative"}
+	minPriceInvalid := filters.MinPrice != nil && *filters.MinPrice < 0
+	if minPriceInvalid {
+		errs.add(ValidationError{Field: "minPrice", Code: CodeOutOfRange, Message: "minimum price cannot be negative"})
 	}
 
-	if filters.MaxPrice != nil && *filters.MaxPrice < 0 {
-		return ValidationError{Field: "MaxPrice", Message: "maximum price cannot be negative"}
+	maxPriceInvalid := filters.MaxPrice != nil && *filters.MaxPrice < 0
+	if maxPriceInvalid {
+		errs.add(ValidationError{Field: "maxPrice", Code: CodeOutOfRange, Message: "maximum price cannot be negative"})
 	}
 
-	if filters.MinPrice != nil && filters.MaxPrice != nil {
+	if filters.MinPrice != nil && filters.MaxPrice != nil && !minPriceInvalid && !maxPriceInvalid {
 		if *filters.MinPrice > *filters.MaxPrice {
-			return ValidationError{
-				Field:   "MaxPrice",
+			errs.add(ValidationError{
+				Field:   "maxPrice",
+				Code:    CodeOutOfRange,
 				Message: "maximum price must be greater than minimum price",
-			}
+			})
+		}
+	}
+
+	// Validate airlines
+	for _, airline := range filters.Airlines {
+		if strings.TrimSpace(airline) == "" {
+			errs.add(ValidationError{Field: "airlines", Code: CodeRequired, Message: "airline cannot be empty"})
+			break
 		}
 	}
 
 	// Validate max stops
 	if filters.MaxStops != nil && *filters.MaxStops < 0 {
-		return ValidationError{Field: "MaxStops", Message: "maximum stops cannot be negative"}
+		errs.add(ValidationError{Field: "maxStops", Code: CodeOutOfRange, Message: "maximum stops cannot be negative"})
 	}
 
 	// Validate time ranges
 	if filters.DepartureTime != nil {
-		if err := v.validateTimeRange(*filters.DepartureTime, "DepartureTime"); err != nil {
-			return err
-		}
+		errs.add(v.validateTimeRange(*filters.DepartureTime, "departureTime"))
 	}
 
 	if filters.ArrivalTime != nil {
-		if err := v.validateTimeRange(*filters.ArrivalTime, "ArrivalTime"); err != nil {
-			return err
-		}
+		errs.add(v.validateTimeRange(*filters.ArrivalTime, "arrivalTime"))
 	}
 
 	// Validate max duration
 	if filters.MaxDuration != nil && *filters.MaxDuration <= 0 {
-		return ValidationError{Field: "MaxDuration", Message: "maximum duration must be positive"}
+		errs.add(ValidationError{Field: "maxDuration", Code: CodeOutOfRange, Message: "maximum duration must be positive"})
 	}
 
-	return nil
+	// Validate required amenities
+	for _, amenity := range filters.RequiredAmenities {
+		if !amenity.IsValid() {
+			errs.add(ValidationError{
+				Field:   "requiredAmenities",
+				Code:    CodeInvalidValue,
+				Message: fmt.Sprintf("invalid amenity %q (expected wifi, meal, snack, entertainment, or power)", amenity),
+			})
+		}
+	}
+
+	// Validate aircraft families
+	for _, family := range filters.AircraftFamilies {
+		if !models.IsKnownAircraftFamily(family) {
+			errs.add(ValidationError{
+				Field:   "aircraftFamilies",
+				Code:    CodeInvalidValue,
+				Message: fmt.Sprintf("invalid aircraft family %q", family),
+			})
+		}
+	}
+
+	return errs.err()
 }
 
 // validateTimeRange validates a time range
 func (v *Validator) validateTimeRange(timeRange models.TimeRange, field string) error {
-	if timeRange.Start < 0 || timeRange.Start > 23 {
-		return ValidationError{
-			Field:   field + ".Start",
+	var errs ValidationErrors
+
+	startInvalid := timeRange.Start < 0 || timeRange.Start > 23
+	if startInvalid {
+		errs.add(ValidationError{
+			Field:   field + ".start",
+			Code:    CodeOutOfRange,
 			Message: "start hour must be between 0 and 23",
-		}
+		})
 	}
 
-	if timeRange.End < 0 || timeRange.End > 23 {
-		return ValidationError{
-			Field:   field + ".End",
+	endInvalid := timeRange.End < 0 || timeRange.End > 23
+	if endInvalid {
+		errs.add(ValidationError{
+			Field:   field + ".end",
+			Code:    CodeOutOfRange,
 			Message: "end hour must be between 0 and 23",
-		}
+		})
 	}
 
-	if timeRange.Start > timeRange.End {
-		return ValidationError{
+	if !startInvalid && !endInvalid && timeRange.Start > timeRange.End {
+		errs.add(ValidationError{
 			Field:   field,
+			Code:    CodeOutOfRange,
 			Message: "start hour must be less than or equal to end hour",
-		}
+		})
 	}
 
-	return nil
+	return errs.err()
 }
 
 // validateDate validates date format and returns parsed time
@@ -211,6 +323,7 @@ func (v *Validator) validateDate(dateStr, field string) (time.Time, error) {
 	if dateStr == "" {
 		return time.Time{}, ValidationError{
 			Field:   field,
+			Code:    CodeRequired,
 			Message: "date is required",
 		}
 	}
@@ -239,6 +352,7 @@ func (v *Validator) validateDate(dateStr, field string) (time.Time, error) {
 	if lastErr != nil {
 		return time.Time{}, ValidationError{
 			Field:   field,
+			Code:    CodeInvalidFormat,
 			Message: "invalid date format (expected YYYY-MM-DD, YYYY/MM/DD, DD-MM-YYYY, or DD/MM/YYYY)",
 		}
 	}
@@ -0,0 +1,135 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"flight-aggregator/pkg/config"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned when a call is rejected because the circuit is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State represents the circuit breaker state
+type State string
+
+// Circuit breaker states
+const (
+	StateClosed   State = "closed"    // Calls pass through, failures are counted
+	StateOpen     State = "open"      // Calls fail fast until the cool-down elapses
+	StateHalfOpen State = "half_open" // A single probe call is allowed through
+)
+
+// Params holds configuration for a circuit breaker
+type Params struct {
+	FailureThreshold int           // consecutive failures before opening
+	CoolDown         time.Duration // time to stay open before probing
+}
+
+// FromConfig creates Params from config.CircuitBreakerConfig
+func FromConfig(cfg config.CircuitBreakerConfig) Params {
+	return Params{
+		FailureThreshold: cfg.FailureThreshold,
+		CoolDown:         cfg.GetCoolDown(),
+	}
+}
+
+// Breaker is a consecutive-failure circuit breaker, safe for concurrent use
+type Breaker struct {
+	mu            sync.Mutex
+	params        Params
+	state         State
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+}
+
+// New creates a new circuit breaker in the closed state
+// A FailureThreshold of 0 or less disables the breaker
+func New(params Params) *Breaker {
+	return &Breaker{
+		params: params,
+		state:  StateClosed,
+	}
+}
+
+// Allow reports whether a call may proceed
+// Callers that are allowed must report the outcome with RecordSuccess, RecordFailure
+// or RecordCancelled
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.params.FailureThreshold <= 0 {
+		return true
+	}
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.params.CoolDown {
+			return false
+		}
+		// Cool-down elapsed: let one probe through
+		b.state = StateHalfOpen
+		b.probeInFlight = true
+		return true
+	case StateHalfOpen:
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess records a successful call and closes the circuit
+func (b *Breaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = StateClosed
+	b.failures = 0
+	b.probeInFlight = false
+}
+
+// RecordFailure records a failed call and opens the circuit once the threshold is reached
+func (b *Breaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.params.FailureThreshold <= 0 {
+		return
+	}
+
+	b.failures++
+	b.probeInFlight = false
+
+	// A failed probe reopens the circuit immediately
+	if b.state == StateHalfOpen || b.failures >= b.params.FailureThreshold {
+		b.state = StateOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// RecordCancelled releases an allowed call that was cancelled by the caller
+// without counting it as a success or failure
+func (b *Breaker) RecordCancelled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probeInFlight = false
+}
+
+// State returns the current circuit state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Report open circuits past their cool-down as ready to probe
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.params.CoolDown {
+		return StateHalfOpen
+	}
+	return b.state
+}
@@ -10,14 +10,16 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	Server    ServerConfig    `yaml:"server"`
-	Cache     CacheConfig     `yaml:"cache"`
-	Provider  ProviderConfig  `yaml:"provider"`
-	Logging   LoggingConfig   `yaml:"logging"`
-	RateLimit RateLimitConfig `yaml:"rate_limit"`
-	Scoring   ScoringConfig   `yaml:"scoring"`
-	Retry     RetryConfig     `yaml:"retry"`
-	MockData  MockDataConfig  `yaml:"mock_data"`
+	Server         ServerConfig         `yaml:"server"`
+	Cache          CacheConfig          `yaml:"cache"`
+	Provider       ProviderConfig       `yaml:"provider"`
+	Logging        LoggingConfig        `yaml:"logging"`
+	RateLimit      RateLimitConfig      `yaml:"rate_limit"`
+	Scoring        ScoringConfig        `yaml:"scoring"`
+	Retry          RetryConfig          `yaml:"retry"`
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+	Admin          AdminConfig          `yaml:"admin"`
+	MockData       MockDataConfig       `yaml:"mock_data"`
 }
 
 type ServerConfig struct {
@@ -26,15 +28,47 @@ type ServerConfig struct {
 	ReadTimeout  string `yaml:"read_timeout"`
 	WriteTimeout string `yaml:"write_timeout"`
 	IdleTimeout  string `yaml:"idle_timeout"`
+
+	SessionIdleTimeout string   `yaml:"session_idle_timeout"` // WebSocket search sessions
+	WebSocketOrigins   []string `yaml:"websocket_origins"`    // cross-origin pages allowed to open WebSocket sessions
+	ValidateResponses  bool     `yaml:"validate_responses"`   // check responses against the OpenAPI spec and log violations
 }
 
 type CacheConfig struct {
-	TTL string `yaml:"ttl"`
+	TTL         string           `yaml:"ttl"`
+	StaleWindow string           `yaml:"stale_window"`  // serve expired results this long while refreshing; 0 disables
+	Backend     string           `yaml:"backend"`       // "memory" (default) or "redis"
+	MaxEntries  int              `yaml:"max_entries"`   // memory backend; 0 means unlimited
+	MaxMemoryMB int              `yaml:"max_memory_mb"` // memory backend, approximate; 0 means unlimited
+	Redis       RedisCacheConfig `yaml:"redis"`
+
+	Warmer CacheWarmerConfig `yaml:"warmer"`
+}
+
+type CacheWarmerConfig struct {
+	Enabled              bool     `yaml:"enabled"`    // start on boot; can also be started via the admin API
+	Interval             string   `yaml:"interval"`   // time between warm runs
+	DaysAhead            int      `yaml:"days_ahead"` // departure dates warmed, starting at start_date
+	StartDate            string   `yaml:"start_date"` // YYYY-MM-DD; empty means today
+	Routes               []string `yaml:"routes"`     // e.g. "CGK-DPS"; empty warms the most searched routes
+	TopRoutes            int      `yaml:"top_routes"` // popular routes warmed when routes is empty
+	Passengers           int      `yaml:"passengers"`
+	CabinClass           string   `yaml:"cabin_class"`
+	MaxSearchesPerMinute int      `yaml:"max_searches_per_minute"` // per provider
+}
+
+type RedisCacheConfig struct {
+	Addr      string `yaml:"addr"`
+	Password  string `yaml:"password"`
+	DB        int    `yaml:"db"`
+	KeyPrefix string `yaml:"key_prefix"`
+	Timeout   string `yaml:"timeout"`
 }
 
 type ProviderConfig struct {
-	Timeout   string                    `yaml:"timeout"`
-	Providers map[string]ProviderDetail `yaml:"providers"`
+	Timeout      string                    `yaml:"timeout"`
+	SoftDeadline string                    `yaml:"soft_deadline"`
+	Providers    map[string]ProviderDetail `yaml:"providers"`
 }
 
 type ProviderDetail struct {
@@ -42,8 +76,32 @@ type ProviderDetail struct {
 	Enabled      bool   `yaml:"enabled"`
 	ResponseTime string `yaml:"response_time"`
 	//ResponseTimeEndRange  int `yaml:"response_time_end_range"` //Real world simulation
-	FailureRate float64 `yaml:"failure_rate"`
-	DataPath    string  `yaml:"data_path"`
+	FailureRate float64         `yaml:"failure_rate"`
+	DataPath    string          `yaml:"data_path"`
+	Carriers    []CarrierDetail `yaml:"carriers"`
+	Routes      []string        `yaml:"routes"` // e.g. "CGK-DPS"; derived from data_path when empty
+
+	// Per-provider overrides; zero values fall back to provider.timeout and retry settings
+	Timeout      string  `yaml:"timeout"`
+	MaxAttempts  int     `yaml:"max_attempts"`
+	InitialDelay string  `yaml:"initial_delay"`
+	MaxDelay     string  `yaml:"max_delay"`
+	Multiplier   float64 `yaml:"multiplier"`
+
+	// Hedged requests: launch a second attempt if the first is slower than hedge_delay (or the observed p95)
+	HedgeEnabled bool   `yaml:"hedge_enabled"`
+	HedgeDelay   string `yaml:"hedge_delay"`
+
+	CacheTTL string `yaml:"cache_ttl"` // how long this provider's results are cached; defaults to cache.ttl
+}
+
+type CarrierDetail struct {
+	Code string `yaml:"code"`
+	Name string `yaml:"name"`
+}
+
+type AdminConfig struct {
+	APIKey string `yaml:"api_key"` // required for /admin endpoints; ADMIN_API_KEY overrides it
 }
 
 type LoggingConfig struct {
@@ -73,6 +131,11 @@ type RetryConfig struct {
 	Multiplier   float64 `yaml:"multiplier"`
 }
 
+type CircuitBreakerConfig struct {
+	FailureThreshold int    `yaml:"failure_threshold"`
+	CoolDown         string `yaml:"cool_down"`
+}
+
 type MockDataConfig struct {
 	Path string `yaml:"path"`
 }
@@ -89,6 +152,11 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to parse .env.yaml: %w", err)
 	}
 
+	// Keep the admin credential out of the config file when set in the environment
+	if apiKey := os.Getenv("ADMIN_API_KEY"); apiKey != "" {
+		config.Admin.APIKey = apiKey
+	}
+
 	return &config, nil
 }
 
@@ -113,16 +181,45 @@ func (s *ServerConfig) GetIdleTimeout() time.Duration {
 	return d
 }
 
+func (s *ServerConfig) GetSessionIdleTimeout() time.Duration {
+	d, _ := time.ParseDuration(s.SessionIdleTimeout)
+	return d
+}
+
 func (c *CacheConfig) GetTTL() time.Duration {
 	d, _ := time.ParseDuration(c.TTL)
 	return d
 }
 
+func (c *CacheConfig) GetStaleWindow() time.Duration {
+	d, _ := time.ParseDuration(c.StaleWindow)
+	return d
+}
+
+func (c *CacheConfig) GetMaxBytes() int64 {
+	return int64(c.MaxMemoryMB) * 1024 * 1024
+}
+
+func (w *CacheWarmerConfig) GetInterval() time.Duration {
+	d, _ := time.ParseDuration(w.Interval)
+	return d
+}
+
+func (r *RedisCacheConfig) GetTimeout() time.Duration {
+	d, _ := time.ParseDuration(r.Timeout)
+	return d
+}
+
 func (p *ProviderConfig) GetTimeout() time.Duration {
 	d, _ := time.ParseDuration(p.Timeout)
 	return d
 }
 
+func (p *ProviderConfig) GetSoftDeadline() time.Duration {
+	d, _ := time.ParseDuration(p.SoftDeadline)
+	return d
+}
+
 func (r *RateLimitConfig) GetWindow() time.Duration {
 	d, _ := time.ParseDuration(r.Window)
 	return d
@@ -133,6 +230,31 @@ func (pd *ProviderDetail) GetResponseTime() time.Duration {
 	return d
 }
 
+func (pd *ProviderDetail) GetTimeout() time.Duration {
+	d, _ := time.ParseDuration(pd.Timeout)
+	return d
+}
+
+func (pd *ProviderDetail) GetInitialDelay() time.Duration {
+	d, _ := time.ParseDuration(pd.InitialDelay)
+	return d
+}
+
+func (pd *ProviderDetail) GetMaxDelay() time.Duration {
+	d, _ := time.ParseDuration(pd.MaxDelay)
+	return d
+}
+
+func (pd *ProviderDetail) GetHedgeDelay() time.Duration {
+	d, _ := time.ParseDuration(pd.HedgeDelay)
+	return d
+}
+
+func (pd *ProviderDetail) GetCacheTTL() time.Duration {
+	d, _ := time.ParseDuration(pd.CacheTTL)
+	return d
+}
+
 func (r *RetryConfig) GetInitialDelay() time.Duration {
 	d, _ := time.ParseDuration(r.InitialDelay)
 	return d
@@ -143,6 +265,11 @@ func (r *RetryConfig) GetMaxDelay() time.Duration {
 	return d
 }
 
+func (c *CircuitBreakerConfig) GetCoolDown() time.Duration {
+	d, _ := time.ParseDuration(c.CoolDown)
+	return d
+}
+
 // GetProviderConfig returns configuration for a specific provider by key
 func (p *ProviderConfig) GetProviderConfig(key string) (*ProviderDetail, bool) {
 	detail, exists := p.Providers[key]